@@ -0,0 +1,177 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// Allure result files, see https://allurereport.org/docs/how-it-works-test-result-file/
+
+type allureResult struct {
+	UUID          string             `json:"uuid"`
+	HistoryID     string             `json:"historyId"`
+	Name          string             `json:"name"`
+	FullName      string             `json:"fullName"`
+	Status        string             `json:"status"`
+	StatusDetails *allureDetails     `json:"statusDetails,omitempty"`
+	Stage         string             `json:"stage"`
+	Start         int64              `json:"start"`
+	Stop          int64              `json:"stop"`
+	Labels        []allureLabel      `json:"labels"`
+	Attachments   []allureAttachment `json:"attachments,omitempty"`
+}
+
+type allureDetails struct {
+	Message string `json:"message,omitempty"`
+	Trace   string `json:"trace,omitempty"`
+}
+
+type allureLabel struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type allureAttachment struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+	Type   string `json:"type"`
+}
+
+// writeAllure writes an Allure result file for every test in pkgs to dir, which is
+// created if needed. The test output is written alongside as a text attachment.
+// Quarantined failures are reported as skipped.
+func writeAllure(dir string, pkgs parse.Packages, opts reportOptions) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, name := range sortedPackageNames(pkgs) {
+		pkg := pkgs[name]
+
+		if pkg.HasPanic {
+			var out strings.Builder
+			for _, e := range pkg.PanicEvents {
+				out.WriteString(e.Output)
+			}
+			testName := pkg.Summary.Test
+			if testName == "" {
+				testName = "panic"
+			}
+			r := newAllureResult(name, testName, opts.labels)
+			r.Status = "broken"
+			r.StatusDetails = &allureDetails{Message: "panic", Trace: out.String()}
+			r.Start, r.Stop = allureMillis(pkg.Summary.Time), allureMillis(pkg.Summary.Time)
+			if err := writeAllureResult(dir, r, out.String()); err != nil {
+				return err
+			}
+			continue
+		}
+
+		for _, t := range pkg.Tests {
+			if t.Name == "" {
+				continue
+			}
+			r := newAllureResult(name, t.Name, opts.labels)
+			switch t.Status() {
+			case parse.ActionPass:
+				r.Status = "passed"
+			case parse.ActionFail:
+				r.Status = "failed"
+				r.StatusDetails = &allureDetails{Message: t.Message(), Trace: t.Stack()}
+				if opts.quarantine.Excused(pkg, t) {
+					r.Status, r.StatusDetails.Message = "skipped", quarantinedMessage(t)
+				}
+			case parse.ActionSkip:
+				r.Status = "skipped"
+				r.StatusDetails = &allureDetails{Message: t.Message()}
+			default:
+				r.Status = "unknown"
+			}
+			// Events are sorted by Status.
+			if len(t.Events) > 0 {
+				r.Start = allureMillis(t.Events[0].Time)
+				r.Stop = allureMillis(t.Events[len(t.Events)-1].Time)
+			}
+			if err := writeAllureResult(dir, r, t.Stack()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func newAllureResult(pkg, test string, labels runLabels) *allureResult {
+	sum := md5.Sum([]byte(pkg + "." + test))
+
+	suite := test
+	if i := strings.Index(test, "/"); i >= 0 {
+		suite = test[:i]
+	}
+	r := &allureResult{
+		UUID:      allureUUID(),
+		HistoryID: hex.EncodeToString(sum[:]),
+		Name:      test,
+		FullName:  pkg + "." + test,
+		Stage:     "finished",
+		Labels: []allureLabel{
+			{Name: "language", Value: "go"},
+			{Name: "framework", Value: "go test"},
+			{Name: "package", Value: pkg},
+			{Name: "parentSuite", Value: pkg},
+			{Name: "suite", Value: suite},
+		},
+	}
+	for _, l := range labels {
+		r.Labels = append(r.Labels, allureLabel{Name: l.key, Value: l.value})
+	}
+	return r
+}
+
+// writeAllureResult writes r, and output as its attachment if not empty.
+func writeAllureResult(dir string, r *allureResult, output string) error {
+	if output != "" {
+		source := r.UUID + "-attachment.txt"
+		if err := ioutil.WriteFile(filepath.Join(dir, source), []byte(output), 0644); err != nil {
+			return err
+		}
+		r.Attachments = append(r.Attachments, allureAttachment{
+			Name:   "output",
+			Source: source,
+			Type:   "text/plain",
+		})
+	}
+
+	by, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, r.UUID+"-result.json"), by, 0644)
+}
+
+// allureUUID returns a random (version 4) UUID.
+func allureUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func allureMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+func TestWriteAllure(t *testing.T) {
+
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "allure-results")
+	pkgs, err := parse.Process(strings.NewReader(quarantineEvents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := reportOptions{
+		quarantine: parse.Quarantine{parse.NewQuarantineRule("example.com/a", "TestFlaky")},
+		labels:     runLabels{{key: "branch", value: "main"}},
+	}
+	if err := writeAllure(dir, pkgs, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := filepath.Glob(filepath.Join(dir, "*-result.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]*allureResult)
+	for _, name := range results {
+		by, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var r allureResult
+		if err := json.Unmarshal(by, &r); err != nil {
+			t.Fatal(err)
+		}
+		got[r.Name] = &r
+	}
+
+	tt := []struct {
+		test, status, message string
+	}{
+		// 0
+		{"TestFlaky", "skipped", "quarantined: flaked"},
+		// 1
+		{"TestBroken", "failed", "broke"},
+	}
+
+	if len(got) != len(tt) {
+		t.Fatalf("got %d results, want %d", len(got), len(tt))
+	}
+	for i, test := range tt {
+		r := got[test.test]
+		if r == nil {
+			t.Fatalf("%d: no result for %s", i, test.test)
+		}
+		if r.Status != test.status {
+			t.Errorf("%d: got status %q, want %q", i, r.Status, test.status)
+		}
+		if r.StatusDetails == nil || r.StatusDetails.Message != test.message {
+			t.Errorf("%d: got details %+v, want message %q", i, r.StatusDetails, test.message)
+		}
+		if r.FullName != "example.com/a."+test.test {
+			t.Errorf("%d: got full name %q", i, r.FullName)
+		}
+		labels := make(map[string]string)
+		for _, l := range r.Labels {
+			labels[l.Name] = l.Value
+		}
+		if labels["package"] != "example.com/a" || labels["branch"] != "main" {
+			t.Errorf("%d: got labels %v, want package and branch", i, labels)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// inAzurePipelines reports whether tparse is running under Azure Pipelines, which
+// sets TF_BUILD for every job.
+func inAzurePipelines() bool {
+	return strings.EqualFold(os.Getenv("TF_BUILD"), "true")
+}
+
+// writeAzureCommands writes Azure Pipelines logging commands: an error issue for every
+// failed test and panicked package, followed by the task result. Quarantined failures
+// are only warnings.
+//
+// See https://learn.microsoft.com/azure/devops/pipelines/scripts/logging-commands
+func writeAzureCommands(w io.Writer, pkgs parse.Packages, exitCode int, opts reportOptions) {
+	for _, name := range sortedPackageNames(pkgs) {
+		pkg := pkgs[name]
+
+		if pkg.HasPanic {
+			fmt.Fprintf(w, "##vso[task.logissue type=error]%s\n",
+				azureEscapeData(fmt.Sprintf("%s: panic in %s", name, pkg.Summary.Test)))
+			continue
+		}
+
+		for _, t := range pkg.TestsByAction(parse.ActionFail) {
+			props, state := "type=error", "failed"
+			if opts.quarantine.Excused(pkg, t) {
+				props, state = "type=warning", "failed (quarantined)"
+			}
+			if loc, ok := t.Location(); ok {
+				props += ";sourcepath=" + azureEscapeProperty(loc.File) + ";linenumber=" + strconv.Itoa(loc.Line)
+			}
+			msg := fmt.Sprintf("%s: %s %s", name, t.Name, state)
+			if m := t.Message(); m != "" {
+				msg += ": " + m
+			}
+			fmt.Fprintf(w, "##vso[task.logissue %s]%s\n", props, azureEscapeData(msg))
+		}
+	}
+
+	result := "Succeeded"
+	if exitCode != 0 {
+		result = "Failed"
+	}
+	fmt.Fprintf(w, "##vso[task.complete result=%s;]tparse finished\n", result)
+}
+
+func azureEscapeData(s string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func azureEscapeProperty(s string) string {
+	return strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A", "]", "%5D", ";", "%3B").Replace(s)
+}
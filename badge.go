@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// badgeCoverage returns the coverage percentage shown by badges: the share of covered
+// statements in the -coverprofile if given, otherwise the mean coverage of packages.
+func badgeCoverage(pkgs parse.Packages) (float64, error) {
+	profile, err := readCoverProfile(*coverProfPtr)
+	if err != nil {
+		return 0, err
+	}
+	if len(profile) > 0 {
+		return profile.Percent(), nil
+	}
+	c, ok := pkgs.Coverage()
+	if !ok {
+		return 0, errors.New("coverage badge: no coverage, run go test with -cover or set -coverprofile")
+	}
+	return c, nil
+}
+
+// badgeColor returns the shields.io color of a coverage percentage, by the
+// -cover-thresholds used for the tables.
+func badgeColor(c float64) (name, hex string) {
+	switch coverageColor(c) {
+	case cRed:
+		return "red", "#e05d44"
+	case cYellow:
+		return "yellow", "#dfb317"
+	default:
+		return "brightgreen", "#4c1"
+	}
+}
+
+// writeBadgeJSON writes a shields.io endpoint file for the coverage badge, to be
+// served from e.g. a gist and shown with https://img.shields.io/endpoint?url=...
+//
+// See https://shields.io/badges/endpoint-badge
+func writeBadgeJSON(w io.Writer, pkgs parse.Packages) error {
+	c, err := badgeCoverage(pkgs)
+	if err != nil {
+		return err
+	}
+	color, _ := badgeColor(c)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		SchemaVersion int    `json:"schemaVersion"`
+		Label         string `json:"label"`
+		Message       string `json:"message"`
+		Color         string `json:"color"`
+	}{1, "coverage", fmt.Sprintf("%.1f%%", c), color})
+}
+
+// badgeSVG is a flat badge in the style of shields.io. Text widths are estimated, as
+// Verdana 11px averages about 7 pixels per character.
+const badgeSVG = `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="coverage: %[4]s">
+  <title>coverage: %[4]s</title>
+  <linearGradient id="s" x2="0" y2="100%%">
+    <stop offset="0" stop-color="#bbb" stop-opacity=".1"/>
+    <stop offset="1" stop-opacity=".1"/>
+  </linearGradient>
+  <clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+  <g clip-path="url(#r)">
+    <rect width="%[2]d" height="20" fill="#555"/>
+    <rect x="%[2]d" width="%[3]d" height="20" fill="%[5]s"/>
+    <rect width="%[1]d" height="20" fill="url(#s)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="%[6]d" y="15" fill="#010101" fill-opacity=".3">coverage</text>
+    <text x="%[6]d" y="14">coverage</text>
+    <text x="%[7]d" y="15" fill="#010101" fill-opacity=".3">%[4]s</text>
+    <text x="%[7]d" y="14">%[4]s</text>
+  </g>
+</svg>
+`
+
+// writeBadgeSVG writes a standalone SVG coverage badge.
+func writeBadgeSVG(w io.Writer, pkgs parse.Packages) error {
+	c, err := badgeCoverage(pkgs)
+	if err != nil {
+		return err
+	}
+	_, hex := badgeColor(c)
+	message := fmt.Sprintf("%.1f%%", c)
+
+	label := len("coverage")*7 + 10
+	value := len(message)*7 + 10
+	_, err = fmt.Fprintf(w, badgeSVG, label+value, label, value, message, hex, label/2, label+value/2)
+	return err
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+	"github.com/olekukonko/tablewriter"
+)
+
+// readBaseline parses the go test -json output file name of a previous run, to compare
+// the tests of this run against. An empty name returns nil. The packages of a run with
+// a data race are returned too, so the race does not disable the comparison.
+func readBaseline(name string) (parse.Packages, error) {
+	if name == "" {
+		return nil, nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pkgs, err := parse.Process(convertReader(f))
+	if err != nil && err != parse.ErrRaceDetected {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return pkgs, nil
+}
+
+// matchPackage reports whether package pkg matches pattern, an import path optionally
+// ending in "/..." to match the packages below it, as with go test.
+func matchPackage(pattern, pkg string) bool {
+	if prefix := strings.TrimSuffix(pattern, "/..."); prefix != pattern {
+		return pkg == prefix || strings.HasPrefix(pkg, prefix+"/")
+	}
+	return pkg == pattern
+}
+
+// missingNewTests returns the packages of pkgs matching any of patterns that have no
+// tests added since the baseline, sorted. Patterns matching no package of this run are
+// returned as is, as their tests did not run.
+func missingNewTests(pkgs parse.Packages, added map[string][]string, patterns []string) []string {
+	var missing []string
+	for _, pattern := range patterns {
+		var matched bool
+		for name := range pkgs {
+			if !matchPackage(pattern, name) {
+				continue
+			}
+			matched = true
+			if len(added[name]) == 0 {
+				missing = append(missing, name)
+			}
+		}
+		if !matched {
+			missing = append(missing, pattern)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// AddedTestsTable prints the tests added since the baseline, by package.
+func (w *consoleWriter) AddedTestsTable(added map[string][]string, trim bool) {
+	s := "\nNew tests since baseline"
+	if len(added) == 0 {
+		fmt.Fprintf(w.Output, "%s: none\n", colorize(s, cYellow, w.Color))
+		return
+	}
+	fmt.Fprintf(w.Output, "%s\n\n", colorize(s, cGreen, w.Color))
+	w.inventoryTable(added, nil, trim)
+}
+
+// RemovedTestsTable prints the tests of the baseline missing from this run, by package.
+// Packages that also have new tests are noted, as their tests may have been renamed.
+func (w *consoleWriter) RemovedTestsTable(removed, added map[string][]string, trim bool) {
+	if len(removed) == 0 {
+		return
+	}
+	s := "\nTests missing since baseline"
+	fmt.Fprintf(w.Output, "%s\n\n", colorize(s, cYellow, w.Color))
+	w.inventoryTable(removed, added, trim)
+}
+
+// inventoryTable prints a table of the tests by package. If renamed is non-nil, a note
+// column counts the new tests of each package.
+func (w *consoleWriter) inventoryTable(tests, renamed map[string][]string, trim bool) {
+	names := make([]string, 0, len(tests))
+	for name := range tests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tbl := tablewriter.NewWriter(w.Output)
+
+	header := []string{"Package", "Count", "Tests"}
+	if renamed != nil {
+		header = append(header, "Note")
+	}
+	tbl.SetHeader(header)
+
+	tbl.SetAutoWrapText(false)
+
+	for _, name := range names {
+		list := make([]string, 0, len(tests[name]))
+		for _, t := range tests[name] {
+			list = append(list, testName(t, trim))
+		}
+		row := []string{
+			filepath.Base(name),
+			strconv.Itoa(len(list)),
+			strings.Join(list, "\n"),
+		}
+		if renamed != nil {
+			note := "--"
+			if n := len(renamed[name]); n > 0 {
+				note = fmt.Sprintf("%d new, may be renamed", n)
+			}
+			row = append(row, note)
+		}
+		tbl.Append(row)
+	}
+
+	tbl.Render()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestReadBaseline(t *testing.T) {
+
+	t.Parallel()
+
+	// A data race in the baseline must not disable the comparison.
+	pkgs, err := readBaseline("parse/testdata/race/input01.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) == 0 {
+		t.Fatal("got no packages for a baseline with a data race")
+	}
+
+	if pkgs, err := readBaseline(""); err != nil || pkgs != nil {
+		t.Errorf("got %v, %v for no baseline, want nil, nil", pkgs, err)
+	}
+	if _, err := readBaseline("parse/testdata/missing.json"); err == nil {
+		t.Error("got no error for a missing baseline")
+	}
+}
+
+func TestMissingNewTests(t *testing.T) {
+
+	t.Parallel()
+
+	pkgs, err := readBaseline("parse/testdata/cached_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := map[string][]string{"strings": {"TestNew"}}
+
+	tt := []struct {
+		patterns []string
+		want     []string
+	}{
+		// 0
+		{[]string{"strings"}, nil},
+		// 1
+		{[]string{"strings", "bytes/..."}, []string{"bytes/..."}},
+		// 2
+		{[]string{"str"}, []string{"str"}},
+	}
+
+	for i, test := range tt {
+		t.Run(fmt.Sprintf("patterns_%d", i), func(t *testing.T) {
+			if got := missingNewTests(pkgs, added, test.patterns); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
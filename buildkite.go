@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// buildkiteStyle returns the annotation style for the run result.
+func buildkiteStyle(exitCode int) string {
+	if exitCode != 0 {
+		return "error"
+	}
+	return "success"
+}
+
+// writeBuildkiteAnnotation renders a Buildkite annotation: the overall result followed by
+// each failure in a collapsible section. Quarantined failures are left out.
+func writeBuildkiteAnnotation(w io.Writer, pkgs parse.Packages, exitCode int, opts reportOptions) error {
+	var sb strings.Builder
+
+	t := pkgs.Totals()
+	result := "passed"
+	if exitCode != 0 {
+		result = "failed"
+	}
+	fmt.Fprintf(&sb, "**Go tests %s**: %d passed, %d failed, %d skipped in %d packages\n",
+		result, t.Passed, t.Failed, t.Skipped, t.Packages)
+
+	for _, name := range sortedPackageNames(pkgs) {
+		pkg := pkgs[name]
+
+		if pkg.HasPanic {
+			var out strings.Builder
+			for _, e := range pkg.PanicEvents {
+				out.WriteString(e.Output)
+			}
+			summary := fmt.Sprintf("<code>%s</code> panicked", html.EscapeString(name))
+			writeMarkdownDetails(&sb, summary, out.String())
+			continue
+		}
+
+		for _, t := range opts.failed(pkg) {
+			summary := fmt.Sprintf("<code>%s</code> in <code>%s</code> (%.2fs)",
+				html.EscapeString(t.Name), html.EscapeString(name), t.Elapsed())
+			writeMarkdownDetails(&sb, summary, failureOutput(t))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// buildkiteAnnotate creates or replaces the tparse annotation on the current build using
+// buildkite-agent.
+func buildkiteAnnotate(pkgs parse.Packages, exitCode int, opts reportOptions) error {
+	var buf bytes.Buffer
+	if err := writeBuildkiteAnnotation(&buf, pkgs, exitCode, opts); err != nil {
+		return err
+	}
+
+	cmd := exec.Command("buildkite-agent", "annotate",
+		"--style", buildkiteStyle(exitCode),
+		"--context", "tparse",
+	)
+	cmd.Stdin = &buf
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// cachedPackages returns the packages whose results go test reused from its cache
+// instead of running their tests, sorted.
+func cachedPackages(pkgs parse.Packages) []string {
+	var cached []string
+	for _, name := range sortedPackageNames(pkgs) {
+		if pkgs[name].Cached {
+			cached = append(cached, name)
+		}
+	}
+	return cached
+}
+
+// reportCached reports each cached package as an issue, failing the run or not.
+func reportCached(w io.Writer, cached []string, fail bool) {
+	title := "warn-on-cached"
+	if fail {
+		title = "fail-on-cached"
+	}
+	for _, name := range cached {
+		reportIssue(w, fail, title, fmt.Sprintf("package %s was not run, its result is cached", name))
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// subcommands are the modes selected by the first argument.
+var subcommands = []string{"completion", "exec", "ingest", "replay", "run", "serve", "stats"}
+
+// completionShells maps shells to their completion scripts. Each script calls
+// tparse __complete with the words of the command line up to the cursor, so flag
+// values are completed by tparse itself and stay in sync with it.
+var completionShells = map[string]string{
+	"bash":       bashCompletion,
+	"zsh":        zshCompletion,
+	"fish":       fishCompletion,
+	"powershell": powershellCompletion,
+}
+
+// flagValues returns the values of flags that take one of a fixed set of values, by
+// flag name. The -output-file formats are completed after the colon of path:format.
+func flagValues() map[string][]string {
+	return map[string][]string{
+		"show-output":   {"failed", "all", "none"},
+		"passthrough":   {"stderr", "section"},
+		"status-style":  presetNames(),
+		"no-test-files": {"ignore", "list", "fail"},
+		"output-file":   reportFormatNames(),
+		"email-when":    {"fail", "always", "schedule"},
+		"email-format":  {"html", "markdown"},
+	}
+}
+
+// runCompletion implements the completion subcommand, writing the completion script
+// for the shell named by args to stdout. It returns the exit code.
+func runCompletion(args []string) int {
+	names := make([]string, 0, len(completionShells))
+	for name := range completionShells {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if len(args) != 1 || completionShells[args[0]] == "" {
+		fmt.Fprintf(os.Stderr, "Error: completion requires a shell, one of %s\n\n", strings.Join(names, ", "))
+		flag.Usage()
+	}
+	fmt.Fprint(os.Stdout, completionShells[args[0]])
+	return 0
+}
+
+// writeCompletions writes the candidates for the last of words, the arguments up to
+// the cursor, one per line. Nothing is written where a file name is expected, so the
+// shell falls back to completing files.
+func writeCompletions(w io.Writer, words []string) {
+	for _, c := range complete(words) {
+		fmt.Fprintln(w, c)
+	}
+}
+
+// complete returns the candidates for the last of words, which is the partial word
+// being completed and may be empty.
+func complete(words []string) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	cur, prev := words[len(words)-1], words[:len(words)-1]
+	// PowerShell cannot pass an empty argument to a native command, so quotes stand in.
+	if cur == `""` {
+		cur = ""
+	}
+
+	// Arguments after "--" are passed to go test or the test binary.
+	for _, w := range prev {
+		if w == "--" {
+			return nil
+		}
+	}
+
+	if len(prev) > 0 && prev[0] == "completion" {
+		if len(prev) > 1 {
+			return nil
+		}
+		var shells []string
+		for name := range completionShells {
+			shells = append(shells, name)
+		}
+		sort.Strings(shells)
+		return withPrefix(shells, cur)
+	}
+
+	// The value of a flag given as a separate word.
+	if n := len(prev); n > 0 && strings.HasPrefix(prev[n-1], "-") && !strings.Contains(prev[n-1], "=") {
+		name := strings.TrimLeft(prev[n-1], "-")
+		if f := flag.Lookup(name); f != nil && !isBoolFlag(f) {
+			return completeValue(name, "", cur)
+		}
+	}
+
+	if strings.HasPrefix(cur, "-") {
+		if i := strings.Index(cur, "="); i > 0 {
+			return completeValue(strings.TrimLeft(cur[:i], "-"), cur[:i+1], cur[i+1:])
+		}
+		dashes := "-"
+		if strings.HasPrefix(cur, "--") {
+			dashes = "--"
+		}
+		var flags []string
+		flag.VisitAll(func(f *flag.Flag) {
+			flags = append(flags, dashes+f.Name)
+		})
+		return withPrefix(flags, cur)
+	}
+
+	if len(prev) == 0 {
+		return withPrefix(subcommands, cur)
+	}
+	return nil
+}
+
+// completeValue returns the candidates for the partial value of flag name, each
+// preceded by prefix.
+func completeValue(name, prefix, value string) []string {
+	values := flagValues()[name]
+	if name == "output-file" {
+		i := strings.LastIndex(value, ":")
+		if i < 0 {
+			return nil
+		}
+		prefix += value[:i+1]
+		value = value[i+1:]
+	}
+	var candidates []string
+	for _, v := range withPrefix(values, value) {
+		candidates = append(candidates, prefix+v)
+	}
+	return candidates
+}
+
+// withPrefix returns the words starting with prefix.
+func withPrefix(words []string, prefix string) []string {
+	var matched []string
+	for _, w := range words {
+		if strings.HasPrefix(w, prefix) {
+			matched = append(matched, w)
+		}
+	}
+	return matched
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+const bashCompletion = `# bash completion for tparse. Load with: source <(tparse completion bash)
+_tparse() {
+    local line="${COMP_LINE:0:$COMP_POINT}"
+    local -a words
+    read -ra words <<< "$line"
+    [[ $line == *[[:space:]] ]] && words+=("")
+
+    local IFS=$'\n'
+    COMPREPLY=($(tparse __complete "${words[@]:1}" 2>/dev/null))
+
+    # Bash splits words at = and :, so candidates lose what precedes the current word.
+    local cur="${words[${#words[@]}-1]}"
+    local prefix="${cur%"${COMP_WORDS[COMP_CWORD]}"}"
+    COMPREPLY=("${COMPREPLY[@]#"$prefix"}")
+}
+complete -o default -o nospace -F _tparse tparse
+`
+
+const zshCompletion = `#compdef tparse
+# zsh completion for tparse. Load with: source <(tparse completion zsh)
+_tparse() {
+    local -a candidates
+    candidates=("${(@f)$(tparse __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ -z ${candidates[1]} ]]; then
+        _files
+        return
+    fi
+    compadd -Q -S '' -- "${candidates[@]}"
+}
+compdef _tparse tparse
+`
+
+const fishCompletion = `# fish completion for tparse. Load with: tparse completion fish | source
+function __tparse_complete
+    set -l words (commandline -opc)[2..-1] (commandline -ct)
+    tparse __complete $words 2>/dev/null
+end
+complete -c tparse -a '(__tparse_complete)'
+`
+
+const powershellCompletion = `# PowerShell completion for tparse. Load with:
+# tparse completion powershell | Out-String | Invoke-Expression
+Register-ArgumentCompleter -Native -CommandName tparse -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 |
+        Where-Object { $_.Extent.EndOffset -le $cursorPosition } | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') { $words += '""' }
+    tparse __complete @words 2>$null | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		words []string
+		want  []string
+	}{
+		// 0
+		{[]string{"st"}, []string{"stats"}},
+		// 1
+		{[]string{"-show-output="}, []string{"-show-output=failed", "-show-output=all", "-show-output=none"}},
+		// 2
+		{[]string{"--email-when", "s"}, []string{"schedule"}},
+		// 3
+		{[]string{"-output-file=report.xml:j"}, []string{"-output-file=report.xml:json", "-output-file=report.xml:junit"}},
+		// 4, no format before the path
+		{[]string{"-output-file=rep"}, nil},
+		// 5
+		{[]string{"completion", "p"}, []string{"powershell"}},
+		// 6, only flags that exist
+		{[]string{"--fo"}, nil},
+		// 7
+		{[]string{"--col"}, nil},
+		// 8
+		{[]string{"--email-f"}, []string{"--email-format"}},
+		// 9, go test arguments
+		{[]string{"run", "--", "-r"}, nil},
+		// 10, a file name
+		{[]string{"-tee", ""}, nil},
+		// 11, PowerShell's empty word
+		{[]string{"-passthrough", `""`}, []string{"stderr", "section"}},
+	}
+
+	for i, test := range tt {
+		t.Run(fmt.Sprintf("words_%d", i), func(t *testing.T) {
+			got := complete(test.words)
+			if strings.Join(got, " ") != strings.Join(test.want, " ") {
+				t.Errorf("got %q, want %q for %q", got, test.want, test.words)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// coverageThresholds are the coverage percentages below which coverage is shown in red
+// and yellow respectively, set with -cover-thresholds.
+var coverageThresholds = [2]float64{50, 80}
+
+// parseCoverageThresholds parses a -cover-thresholds value, such as "50,80".
+func parseCoverageThresholds(s string) ([2]float64, error) {
+	var t [2]float64
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return t, fmt.Errorf("invalid coverage thresholds %q: want red,yellow, e.g. 50,80", s)
+	}
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(p, "%")), 64)
+		if err != nil || f < 0 || f > 100 {
+			return t, fmt.Errorf("invalid coverage threshold %q: must be a percentage", p)
+		}
+		t[i] = f
+	}
+	if t[0] > t[1] {
+		return t, fmt.Errorf("invalid coverage thresholds %q: red must not exceed yellow", s)
+	}
+	return t, nil
+}
+
+// coverageColor returns the color for a coverage percentage.
+func coverageColor(c float64) int {
+	switch {
+	case c < coverageThresholds[0]:
+		return cRed
+	case c < coverageThresholds[1]:
+		return cYellow
+	default:
+		return cGreen
+	}
+}
+
+// coverageBarWidth is the number of cells in a coverage bar.
+const coverageBarWidth = 10
+
+var barEighths = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// coverageBar renders a coverage percentage as a bar of block characters, with eighths
+// of a cell for precision.
+func coverageBar(c float64) string {
+	if c < 0 {
+		c = 0
+	}
+	if c > 100 {
+		c = 100
+	}
+	eighths := int(c/100*coverageBarWidth*8 + 0.5)
+	full, part := eighths/8, eighths%8
+
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat("█", full))
+	cells := full
+	if part > 0 {
+		sb.WriteString(barEighths[part])
+		cells++
+	}
+	sb.WriteString(strings.Repeat("░", coverageBarWidth-cells))
+	return sb.String()
+}
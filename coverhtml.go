@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// writeCoverHTML splits the coverage profile in the named file by package and renders
+// each with go tool cover -html into dir. It returns the absolute path of the page
+// written for each package.
+func writeCoverHTML(dir, profile string) (map[string]string, error) {
+	by, err := ioutil.ReadFile(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blocks are grouped by the package of their file, keeping the mode line for each.
+	var mode string
+	blocks := make(map[string][]string)
+	sc := bufio.NewScanner(strings.NewReader(string(by)))
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "mode:") {
+			mode = line
+			continue
+		}
+		i := strings.LastIndex(line, ":")
+		if i < 0 {
+			continue
+		}
+		pkg := path.Dir(line[:i])
+		blocks[pkg] = append(blocks[pkg], line)
+	}
+	if mode == "" {
+		return nil, fmt.Errorf("%s: not a coverage profile", profile)
+	}
+
+	if dir, err = filepath.Abs(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(blocks))
+	for pkg := range blocks {
+		names = append(names, pkg)
+	}
+	sort.Strings(names)
+
+	pages := make(map[string]string)
+	for _, pkg := range names {
+		f, err := ioutil.TempFile("", "tparse-cover-*.out")
+		if err != nil {
+			return pages, err
+		}
+		fmt.Fprintln(f, mode)
+		fmt.Fprintln(f, strings.Join(blocks[pkg], "\n"))
+		f.Close()
+
+		page := filepath.Join(dir, strings.Replace(pkg, "/", "_", -1)+".html")
+		cmd := exec.Command("go", "tool", "cover", "-html="+f.Name(), "-o", page)
+		cmd.Stderr = os.Stderr
+		err = cmd.Run()
+		os.Remove(f.Name())
+		if err != nil {
+			return pages, fmt.Errorf("go tool cover for %s: %v", pkg, err)
+		}
+		pages[pkg] = page
+	}
+	return pages, nil
+}
+
+// linkCoverPages wraps the package names in the rendered summary table s in terminal
+// hyperlinks to their coverage pages.
+func linkCoverPages(s string, pages map[string]string) string {
+	urls := make(map[string]string)
+	for pkg, page := range pages {
+		page = filepath.ToSlash(page)
+		if !strings.HasPrefix(page, "/") {
+			page = "/" + page // Windows drive letter.
+		}
+		urls[pkg] = "file://" + page
+	}
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		for pkg, url := range urls {
+			cell := " " + pkg + " "
+			if j := strings.Index(line, cell); j >= 0 {
+				lines[i] = line[:j+1] + hyperlink(url, pkg) + line[j+1+len(pkg):]
+				break
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
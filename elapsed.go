@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// elapsedOverrun is the run or a package taking longer than -max-elapsed.
+type elapsedOverrun struct {
+	// pkg is empty for the run as a whole.
+	pkg     string
+	elapsed time.Duration
+}
+
+func (o elapsedOverrun) String() string {
+	what := "test run"
+	if o.pkg != "" {
+		what = "package " + o.pkg
+	}
+	return fmt.Sprintf("%s took %v, more than -max-elapsed=%v", what, o.elapsed, *maxElapsedPtr)
+}
+
+// findOverruns returns the packages whose wall time exceeds max, sorted by package,
+// preceded by the run as a whole if it does.
+func findOverruns(pkgs parse.Packages, max time.Duration) []elapsedOverrun {
+	seconds := func(s float64) time.Duration {
+		return time.Duration(s*1000) * time.Millisecond
+	}
+
+	var overruns []elapsedOverrun
+	if d := seconds(pkgs.Wall()); d > max {
+		overruns = append(overruns, elapsedOverrun{elapsed: d})
+	}
+	for _, name := range sortedPackageNames(pkgs) {
+		pkg := pkgs[name]
+		if pkg.Summary == nil || pkg.Cached {
+			continue
+		}
+		if d := seconds(pkg.Wall()); d > max {
+			overruns = append(overruns, elapsedOverrun{pkg: name, elapsed: d})
+		}
+	}
+	return overruns
+}
+
+// reportOverruns reports each overrun as an issue, failing the run or not.
+func reportOverruns(w io.Writer, overruns []elapsedOverrun, fail bool) {
+	for _, o := range overruns {
+		reportIssue(w, fail, "max-elapsed", o.String())
+	}
+}
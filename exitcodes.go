@@ -0,0 +1,44 @@
+package main
+
+import "github.com/mfridman/tparse/parse"
+
+// Exit codes used with -detailed-exit-codes, documented in the README. Without it,
+// tparse exits with 1 on any failure, as go test does. The flag package exits with 2
+// on usage errors.
+const (
+	exitTestFailure  = 1
+	exitBuildFailure = 3
+	exitRace         = 4
+	exitPanic        = 5
+	exitTparseError  = 6
+	exitMaxElapsed   = 7
+	exitCached       = 8
+	exitNoTestFiles  = 9
+)
+
+// failureCode returns the exit code for a kind of failure: detailed, one of the codes
+// above, with -detailed-exit-codes, and otherwise 1.
+func failureCode(detailed int) int {
+	if *exitCodesPtr {
+		return detailed
+	}
+	return 1
+}
+
+// detailedExitCode returns the exit code for a failed run, exitCode being non-zero, by
+// the most severe kind of failure: a panic, then a build failure, then test failures.
+func detailedExitCode(pkgs parse.Packages, exitCode int) int {
+	if !*exitCodesPtr || exitCode == 0 {
+		return exitCode
+	}
+	code := exitTestFailure
+	for _, pkg := range pkgs {
+		switch {
+		case pkg.HasPanic:
+			return exitPanic
+		case pkg.BuildFailed:
+			code = exitBuildFailure
+		}
+	}
+	return code
+}
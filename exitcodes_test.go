@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestFailureCode(t *testing.T) {
+
+	defer func(detailed bool) { *exitCodesPtr = detailed }(*exitCodesPtr)
+
+	for _, code := range []int{exitRace, exitPanic, exitTparseError, exitMaxElapsed, exitCached, exitNoTestFiles} {
+		*exitCodesPtr = false
+		if got := failureCode(code); got != 1 {
+			t.Errorf("got %d for %d without -detailed-exit-codes, want 1", got, code)
+		}
+		*exitCodesPtr = true
+		if got := failureCode(code); got != code {
+			t.Errorf("got %d with -detailed-exit-codes, want %d", got, code)
+		}
+	}
+}
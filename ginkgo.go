@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// readGinkgoReports parses the named Ginkgo JSON reports, written by ginkgo
+// --json-report, into packages, to be merged with those of the go test output.
+func readGinkgoReports(names []string, redactor *parse.Redactor) (parse.Packages, error) {
+	var reports []parse.Packages
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		pkgs, err := parse.Process(convertReader(f), parse.WithRedactor(redactor))
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		reports = append(reports, pkgs)
+	}
+	return parse.MergeShards(reports), nil
+}
+
+// importPath returns the import path of the package in directory dir, as recorded as
+// the suite path of a Ginkgo report, from the go.mod of its module. It returns dir if
+// no go.mod is found.
+func importPath(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for d := abs; ; d = filepath.Dir(d) {
+		if mod := readModulePath(filepath.Join(d, "go.mod")); mod != "" {
+			rel, err := filepath.Rel(d, abs)
+			if err != nil {
+				return dir
+			}
+			return path.Join(mod, filepath.ToSlash(rel))
+		}
+		if filepath.Dir(d) == d {
+			return dir
+		}
+	}
+}
+
+// reportFocused warns of the Ginkgo suites that ran only their programmatically
+// focused specs, which Ginkgo fails so that focus is not committed by mistake.
+func reportFocused(w io.Writer, pkgs parse.Packages) {
+	for _, name := range sortedPackageNames(pkgs) {
+		s := pkgs[name].Ginkgo
+		if s == nil || !s.Focused {
+			continue
+		}
+		reportIssue(w, false, "ginkgo-focus", fmt.Sprintf("package %s ran %d of %d specs: remove programmatic focus, such as FIt or FDescribe", name, s.Ran, s.Specs))
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// githubClient posts pull request comments through the GitHub REST API.
+type githubClient struct {
+	apiURL string
+	token  string
+	repo   string // owner/name
+	client *http.Client
+}
+
+// newGithubClient returns a client configured from the environment variables set by
+// GitHub Actions: GITHUB_TOKEN, GITHUB_REPOSITORY and, optionally, GITHUB_API_URL.
+func newGithubClient() (*githubClient, error) {
+	c := &githubClient{
+		apiURL: strings.TrimRight(os.Getenv("GITHUB_API_URL"), "/"),
+		token:  os.Getenv("GITHUB_TOKEN"),
+		repo:   os.Getenv("GITHUB_REPOSITORY"),
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+	if c.apiURL == "" {
+		c.apiURL = "https://api.github.com"
+	}
+	if c.token == "" {
+		return nil, errors.New("GITHUB_TOKEN must be set")
+	}
+	if c.repo == "" {
+		return nil, errors.New("GITHUB_REPOSITORY must be set")
+	}
+	return c, nil
+}
+
+// githubPullRequest returns the pull request number: n if positive, otherwise the
+// number found in the event payload at GITHUB_EVENT_PATH.
+func githubPullRequest(n int) (int, error) {
+	if n > 0 {
+		return n, nil
+	}
+	path := os.Getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		return 0, errors.New("no pull request number: set -github-pr or GITHUB_EVENT_PATH")
+	}
+	by, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var event struct {
+		Number      int `json:"number"`
+		PullRequest struct {
+			Number int `json:"number"`
+		} `json:"pull_request"`
+	}
+	if err := json.Unmarshal(by, &event); err != nil {
+		return 0, errors.Wrap(err, "failed to decode GitHub event")
+	}
+	if event.PullRequest.Number > 0 {
+		return event.PullRequest.Number, nil
+	}
+	if event.Number > 0 {
+		return event.Number, nil
+	}
+	return 0, errors.New("GitHub event is not a pull request")
+}
+
+type githubComment struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// UpsertComment updates the first comment on the pull request containing marker,
+// or creates a new comment if there is none.
+func (c *githubClient) UpsertComment(pr int, marker, body string) error {
+	id, err := c.findComment(pr, marker)
+	if err != nil {
+		return err
+	}
+	payload := map[string]string{"body": body}
+	if id == 0 {
+		return c.do("POST", fmt.Sprintf("/repos/%s/issues/%d/comments", c.repo, pr), payload, nil)
+	}
+	return c.do("PATCH", fmt.Sprintf("/repos/%s/issues/comments/%d", c.repo, id), payload, nil)
+}
+
+// findComment returns the ID of the first comment containing marker, or zero.
+func (c *githubClient) findComment(pr int, marker string) (int64, error) {
+	for page := 1; ; page++ {
+		var comments []githubComment
+		path := fmt.Sprintf("/repos/%s/issues/%d/comments?per_page=100&page=%d", c.repo, pr, page)
+		if err := c.do("GET", path, nil, &comments); err != nil {
+			return 0, err
+		}
+		for _, comment := range comments {
+			if strings.Contains(comment.Body, marker) {
+				return comment.ID, nil
+			}
+		}
+		if len(comments) < 100 {
+			return 0, nil
+		}
+	}
+}
+
+func (c *githubClient) do(method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		by, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(by)
+	}
+
+	req, err := http.NewRequest(method, c.apiURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "token "+c.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("github: %s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(msg))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGithubPullRequest(t *testing.T) {
+
+	defer os.Setenv("GITHUB_EVENT_PATH", os.Getenv("GITHUB_EVENT_PATH"))
+	dir := t.TempDir()
+
+	tt := []struct {
+		n     int
+		event string
+		want  int
+		err   bool
+	}{
+		// 0, from -github-pr
+		{7, "", 7, false},
+		// 1
+		{0, `{"number": 3, "pull_request": {"number": 3}}`, 3, false},
+		// 2
+		{0, `{"number": 4, "pull_request": {"number": 5}}`, 5, false},
+		// 3, a push event
+		{0, `{"ref": "refs/heads/main"}`, 0, true},
+		// 4
+		{0, `not json`, 0, true},
+		// 5, no event
+		{0, "", 0, true},
+	}
+
+	for i, test := range tt {
+		os.Unsetenv("GITHUB_EVENT_PATH")
+		if test.event != "" {
+			name := filepath.Join(dir, fmt.Sprintf("event%d.json", i))
+			if err := ioutil.WriteFile(name, []byte(test.event), 0644); err != nil {
+				t.Fatal(err)
+			}
+			os.Setenv("GITHUB_EVENT_PATH", name)
+		}
+		got, err := githubPullRequest(test.n)
+		if (err != nil) != test.err {
+			t.Errorf("%d: got error %v, want error %t", i, err, test.err)
+		}
+		if got != test.want {
+			t.Errorf("%d: got %d, want %d", i, got, test.want)
+		}
+	}
+}
+
+func TestUpsertComment(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		name     string
+		comments []githubComment
+		want     string
+	}{
+		{"create", []githubComment{{ID: 1, Body: "LGTM"}}, "POST /repos/o/r/issues/2/comments"},
+		{"update", []githubComment{{ID: 1, Body: "LGTM"}, {ID: 9, Body: "old " + markdownMarker}}, "PATCH /repos/o/r/issues/comments/9"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			var got, body string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != "token secret" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if r.Method == http.MethodGet {
+					json.NewEncoder(w).Encode(test.comments)
+					return
+				}
+				got = r.Method + " " + r.URL.Path
+				var payload map[string]string
+				json.NewDecoder(r.Body).Decode(&payload)
+				body = payload["body"]
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer srv.Close()
+
+			c := &githubClient{apiURL: srv.URL, token: "secret", repo: "o/r", client: srv.Client()}
+			if err := c.UpsertComment(2, markdownMarker, markdownMarker+"\nsummary"); err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+			if !strings.HasSuffix(body, "summary") {
+				t.Errorf("got body %q", body)
+			}
+		})
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Bad credentials", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	c := &githubClient{apiURL: srv.URL, token: "wrong", repo: "o/r", client: srv.Client()}
+	if err := c.UpsertComment(2, markdownMarker, "summary"); err == nil || !strings.Contains(err.Error(), "Bad credentials") {
+		t.Errorf("got error %v, want the API error", err)
+	}
+}
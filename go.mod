@@ -2,7 +2,7 @@ module github.com/mfridman/tparse
 
 require (
 	github.com/mattn/go-colorable v0.0.9
-	github.com/mattn/go-isatty v0.0.4 // indirect
+	github.com/mattn/go-isatty v0.0.4
 	github.com/mattn/go-runewidth v0.0.4 // indirect
 	github.com/olekukonko/tablewriter v0.0.1
 	github.com/pkg/errors v0.8.1
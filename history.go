@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mfridman/tparse/parse"
+	"github.com/olekukonko/tablewriter"
+)
+
+// runStats implements stats mode: it parses each named file as a separate run and
+// prints the aggregated history of every test. It returns the exit code.
+func runStats(names []string) int {
+	if len(names) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: stats requires one or more files, one per run\n\n")
+		flag.Usage()
+	}
+
+	redactor, err := newRedactor()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tparse error: %v\n", err)
+		return failureCode(exitTparseError)
+	}
+
+	var runs []parse.Packages
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tparse error: %v\n", err)
+			return failureCode(exitTparseError)
+		}
+		pkgs, err := parse.Process(convertReader(f), parse.WithRedactor(redactor))
+		f.Close()
+		if err != nil && err != parse.ErrRaceDetected {
+			fmt.Fprintf(os.Stderr, "tparse error: %s: %v\n", name, err)
+			return failureCode(exitTparseError)
+		}
+		runs = append(runs, pkgs)
+	}
+
+	w := newWriter(0)
+	w.HistoryTable(parse.Aggregate(runs), len(runs), *smallScreenPtr)
+	return 0
+}
+
+// HistoryTable prints the history of tests across runs, least stable first and then
+// slowest first.
+func (w *consoleWriter) HistoryTable(history []*parse.TestHistory, runs int, trim bool) {
+	sort.SliceStable(history, func(i, j int) bool {
+		if ri, rj := history[i].FailureRate(), history[j].FailureRate(); ri != rj {
+			return ri > rj
+		}
+		return history[i].Mean > history[j].Mean
+	})
+
+	fmt.Fprintf(w.Output, "\n%d runs\n\n", runs)
+
+	tbl := tablewriter.NewWriter(w.Output)
+
+	tbl.SetHeader([]string{
+		"Test",
+		"Package",
+		"Runs",
+		"Fail",
+		"Mean",
+		"StdDev",
+		"Last Failed",
+	})
+
+	tbl.SetAutoWrapText(false)
+
+	for _, h := range history {
+		fail := strconv.FormatFloat(h.FailureRate()*100, 'f', 1, 64) + "%"
+		if h.Failures > 0 {
+			fail = colorize(fail, cRed, w.Color)
+		}
+		lastFailed := "--"
+		if !h.LastFailed.IsZero() {
+			lastFailed = h.LastFailed.Local().Format(time.RFC3339)
+		}
+		tbl.Append([]string{
+			testName(h.Name, trim),
+			filepath.Base(h.Package),
+			strconv.Itoa(h.Runs),
+			fail,
+			strconv.FormatFloat(h.Mean, 'f', 2, 64) + "s",
+			strconv.FormatFloat(h.StdDev, 'f', 2, 64) + "s",
+			lastFailed,
+		})
+	}
+
+	if tbl.NumLines() > 0 {
+		tbl.Render()
+	}
+}
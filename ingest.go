@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+const (
+	// maxChunkSize is the largest chunk of go test -json output accepted in one request.
+	maxChunkSize = 32 << 20
+	// maxIngestSize is the most output buffered across all runs not yet reported.
+	maxIngestSize = 512 << 20
+	// maxUploads is the most chunks read at the same time.
+	maxUploads = 8
+)
+
+// ingestIDRe matches run and shard IDs, which name files.
+var ingestIDRe = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// runIngest implements ingest mode: it serves an HTTP API on -addr accepting go test
+// -json output from the shards of distributed runs, and writes a combined report of
+// each run to -ingest-dir once all of its shards are done. It returns the exit code.
+//
+//	POST /runs/{run}/{shard}       appends the body to the output of the shard
+//	POST /runs/{run}/{shard}/done  marks the shard as done
+//	GET  /runs/{run}               returns the status of the run
+//
+// The number of shards of a run is given by -shards or a shards query parameter.
+// Requests must carry the token of TPARSE_INGEST_TOKEN as a bearer token.
+func runIngest() int {
+	token := os.Getenv("TPARSE_INGEST_TOKEN")
+	if token == "" {
+		fmt.Fprintln(os.Stderr, "tparse error: TPARSE_INGEST_TOKEN must be set to the token shards authenticate with")
+		return failureCode(exitTparseError)
+	}
+	redactor, err := newRedactor()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tparse error: %v\n", err)
+		return failureCode(exitTparseError)
+	}
+	if err := os.MkdirAll(*ingestDirPtr, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "tparse error: %v\n", err)
+		return failureCode(exitTparseError)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/runs/", newIngestServer(*ingestDirPtr, *shardsPtr, token, redactor))
+
+	fmt.Fprintf(os.Stderr, "tparse: ingesting test output on http://%s/runs/\n", dashboardHost(*addrPtr))
+	if err := http.ListenAndServe(*addrPtr, mux); err != nil {
+		fmt.Fprintf(os.Stderr, "tparse error: %v\n", err)
+		return failureCode(exitTparseError)
+	}
+	return 0
+}
+
+// ingestServer collects the output of the shards of distributed runs.
+type ingestServer struct {
+	dir      string
+	shards   int
+	token    string
+	redactor *parse.Redactor
+	limit    int
+	uploads  chan struct{}
+
+	mu       sync.Mutex
+	runs     map[string]*ingestRun
+	buffered int
+}
+
+func newIngestServer(dir string, shards int, token string, redactor *parse.Redactor) *ingestServer {
+	return &ingestServer{
+		dir:      dir,
+		shards:   shards,
+		token:    token,
+		redactor: redactor,
+		limit:    maxIngestSize,
+		uploads:  make(chan struct{}, maxUploads),
+		runs:     make(map[string]*ingestRun),
+	}
+}
+
+// ingestRun is a run whose shards post their output.
+type ingestRun struct {
+	shards int
+	output map[string]*bytes.Buffer
+	done   map[string]bool
+
+	// reporting is set while the report of the run is written.
+	reporting bool
+	// summary is set once all shards are done and the report is written.
+	summary *streamSummary
+}
+
+// ingestStatus is the JSON returned for a run.
+type ingestStatus struct {
+	Run     string         `json:"run"`
+	Shards  int            `json:"shards"`
+	Done    []string       `json:"done"`
+	Summary *streamSummary `json:"summary,omitempty"`
+}
+
+func (s *ingestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(auth), []byte(s.token)) != 1 {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/runs/"), "/"), "/")
+	for _, p := range parts {
+		if !ingestIDRe.MatchString(p) {
+			http.Error(w, fmt.Sprintf("invalid id %q", p), http.StatusBadRequest)
+			return
+		}
+	}
+
+	id := parts[0]
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		run, ok := s.runs[id]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		s.writeStatus(w, id, run)
+	case len(parts) == 2 && r.Method == http.MethodPost:
+		s.appendChunk(w, r, id, parts[1])
+	case len(parts) == 3 && parts[2] == "done" && r.Method == http.MethodPost:
+		s.markDone(w, r, id, parts[1])
+	default:
+		http.Error(w, "unknown endpoint", http.StatusNotFound)
+	}
+}
+
+// appendChunk appends the body of r to the output of the shard. The body is read
+// before taking s.mu, so slow shards do not hold up the others.
+func (s *ingestServer) appendChunk(w http.ResponseWriter, r *http.Request, id, shard string) {
+	s.uploads <- struct{}{}
+	var chunk bytes.Buffer
+	_, err := io.Copy(&chunk, http.MaxBytesReader(w, r.Body, maxChunkSize))
+	<-s.uploads
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, err := s.run(id, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if run.reporting || run.summary != nil || run.done[shard] {
+		http.Error(w, "shard already done", http.StatusConflict)
+		return
+	}
+	if s.buffered+chunk.Len() > s.limit {
+		http.Error(w, "too much output buffered, try again once runs are reported", http.StatusRequestEntityTooLarge)
+		return
+	}
+	buf, ok := run.output[shard]
+	if !ok {
+		buf = new(bytes.Buffer)
+		run.output[shard] = buf
+	}
+	buf.Write(chunk.Bytes())
+	s.buffered += chunk.Len()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// markDone marks the shard as done, and once all shards of the run are, reports the
+// run without holding s.mu.
+func (s *ingestServer) markDone(w http.ResponseWriter, r *http.Request, id, shard string) {
+	s.mu.Lock()
+	run, err := s.run(id, r)
+	if err != nil {
+		s.mu.Unlock()
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if run.reporting || run.summary != nil {
+		s.writeStatus(w, id, run)
+		s.mu.Unlock()
+		return
+	}
+	run.done[shard] = true
+	if len(run.done) < run.shards {
+		s.writeStatus(w, id, run)
+		s.mu.Unlock()
+		return
+	}
+	// No more output is appended once the run is reporting.
+	run.reporting = true
+	output, shards := run.output, len(run.done)
+	s.mu.Unlock()
+
+	summary, err := s.report(id, output, shards)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.reporting = false
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tparse error: run %s: %v\n", id, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	run.summary = summary
+	// The output is in the reports now.
+	for _, buf := range run.output {
+		s.buffered -= buf.Len()
+	}
+	run.output = nil
+	s.writeStatus(w, id, run)
+}
+
+// run returns the run id, created on its first request. s.mu must be held.
+func (s *ingestServer) run(id string, r *http.Request) (*ingestRun, error) {
+	run, ok := s.runs[id]
+	if !ok {
+		run = &ingestRun{
+			shards: s.shards,
+			output: make(map[string]*bytes.Buffer),
+			done:   make(map[string]bool),
+		}
+	}
+	if v := r.URL.Query().Get("shards"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid shards %q: must be a positive number", v)
+		}
+		run.shards = n
+	}
+	if run.shards < 1 {
+		return nil, fmt.Errorf("unknown number of shards: set the shards query parameter or -shards")
+	}
+	s.runs[id] = run
+	return run, nil
+}
+
+func (s *ingestServer) writeStatus(w http.ResponseWriter, id string, run *ingestRun) {
+	status := ingestStatus{Run: id, Shards: run.shards, Done: []string{}, Summary: run.summary}
+	for shard := range run.done {
+		status.Done = append(status.Done, shard)
+	}
+	sort.Strings(status.Done)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// report combines the output of the shards of run id, writing the redacted output
+// and markdown and JUnit reports to the ingest directory, and printing its summary.
+func (s *ingestServer) report(id string, output map[string]*bytes.Buffer, done int) (*streamSummary, error) {
+	names := make([]string, 0, len(output))
+	for shard := range output {
+		names = append(names, shard)
+	}
+	sort.Strings(names)
+
+	var raw []io.Reader
+	var shards []parse.Packages
+	for _, shard := range names {
+		out := output[shard].Bytes()
+		raw = append(raw, bytes.NewReader(out))
+		pkgs, err := parse.Process(bytes.NewReader(out), parse.WithRedactor(s.redactor))
+		switch err {
+		case nil, parse.ErrRaceDetected:
+		case parse.ErrNotParseable:
+			// A shard may have run no packages.
+			continue
+		default:
+			return nil, fmt.Errorf("shard %s: %v", shard, err)
+		}
+		shards = append(shards, pkgs)
+	}
+	pkgs := parse.MergeShards(shards)
+	exitCode := pkgs.ExitCode()
+
+	base := filepath.Join(s.dir, id)
+	if err := writeFile(base+".json", func(w io.Writer) error {
+		if s.redactor == nil {
+			_, err := io.Copy(w, io.MultiReader(raw...))
+			return err
+		}
+		return s.redactor.RedactStream(w, io.MultiReader(raw...))
+	}); err != nil {
+		return nil, err
+	}
+	if err := writeFile(base+".md", func(w io.Writer) error {
+		return writeMarkdown(w, pkgs, exitCode, reportOptions{})
+	}); err != nil {
+		return nil, err
+	}
+	if err := writeFile(base+".xml", func(w io.Writer) error {
+		return writeJUnit(w, pkgs, reportOptions{})
+	}); err != nil {
+		return nil, err
+	}
+
+	summary := newStreamSummary(pkgs, true)
+	w := newWriter(0)
+	fmt.Fprintf(w.Output, "\nRun %s: %d shard(s), reports in %s.{json,md,xml}\n", id, done, base)
+	if len(pkgs) > 0 {
+		w.SummaryTable(pkgs, *showNoTestsPtr, !*noSubtestsPtr)
+	}
+	return &summary, nil
+}
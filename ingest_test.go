@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+func TestIngest(t *testing.T) {
+
+	dir := t.TempDir()
+	redactor, err := parse.NewRedactor([]string{`s3cr3t`}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(newIngestServer(dir, 2, "token", redactor))
+	defer srv.Close()
+
+	post := func(path string, body io.Reader) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, srv.URL+path, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer token")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+	postFile := func(path, name string) {
+		t.Helper()
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if resp := post(path, f); resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("POST %s: got status %d, want %d", path, resp.StatusCode, http.StatusNoContent)
+		}
+	}
+
+	resp, err := http.Post(srv.URL+"/runs/1/a", "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("POST without token: got status %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	postFile("/runs/1/a", "parse/testdata/cached_test.json")
+	postFile("/runs/1/a", "parse/testdata/race/input01.json")
+	secret := `{"Action":"output","Package":"strings","Output":"token s3cr3t\n"}` + "\n"
+	if resp := post("/runs/1/b", strings.NewReader(secret)); resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("POST: got status %d", resp.StatusCode)
+	}
+	if resp := post("/runs/1/a/done", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST done: got status %d", resp.StatusCode)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "1.json")); !os.IsNotExist(err) {
+		t.Fatalf("run reported before all shards were done: %v", err)
+	}
+	if resp := post("/runs/1/b/done", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST done: got status %d", resp.StatusCode)
+	}
+	if resp := post("/runs/1/b", strings.NewReader(secret)); resp.StatusCode != http.StatusConflict {
+		t.Errorf("POST after done: got status %d, want %d", resp.StatusCode, http.StatusConflict)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/runs/1", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var status ingestStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status.Summary == nil || status.Summary.Status != parse.ActionFail {
+		t.Errorf("got summary %+v, want a failed run for the data race", status.Summary)
+	}
+	if len(status.Done) != 2 {
+		t.Errorf("got done shards %v, want [a b]", status.Done)
+	}
+
+	raw, err := ioutil.ReadFile(filepath.Join(dir, "1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "s3cr3t") || !strings.Contains(string(raw), parse.Redacted) {
+		t.Errorf("raw output is not redacted")
+	}
+	for _, ext := range []string{".md", ".xml"} {
+		if _, err := os.Stat(filepath.Join(dir, "1"+ext)); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestIngestLimit(t *testing.T) {
+
+	s := newIngestServer(t.TempDir(), 1, "token", nil)
+	s.limit = 10
+
+	tt := []struct {
+		body string
+		want int
+	}{
+		// 0
+		{"0123456789", http.StatusNoContent},
+		// 1
+		{"0", http.StatusRequestEntityTooLarge},
+	}
+
+	for i, test := range tt {
+		req := httptest.NewRequest(http.MethodPost, "/runs/1/a", strings.NewReader(test.body))
+		req.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != test.want {
+			t.Errorf("%d: got status %d, want %d", i, w.Code, test.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// inGitHubActions reports whether tparse is running under GitHub Actions.
+func inGitHubActions() bool {
+	return strings.EqualFold(os.Getenv("GITHUB_ACTIONS"), "true")
+}
+
+// reportIssue prints msg to w as a tparse warning, or an error if it fails the run,
+// along with an annotation titled after the option that raised it when running in
+// GitHub Actions or Azure Pipelines.
+func reportIssue(w io.Writer, fail bool, title, msg string) {
+	level := "warning"
+	if fail {
+		level = "error"
+	}
+	fmt.Fprintf(w, "tparse %s: %s\n", level, msg)
+	switch {
+	case inGitHubActions():
+		fmt.Fprintf(os.Stdout, "::%s title=%s::%s\n", level, title,
+			strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(msg))
+	case inAzurePipelines():
+		fmt.Fprintf(os.Stdout, "##vso[task.logissue type=%s]%s\n", level, azureEscapeData(msg))
+	}
+}
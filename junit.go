@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// JUnit XML, in the dialect accepted by CircleCI store_test_results and most other
+// CI systems.
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Time       string           `xml:"time,attr"`
+	Timestamp  string           `xml:"timestamp,attr,omitempty"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Cases      []junitTestCase  `xml:"testcase"`
+}
+
+// junitProperties is a pointer in junitTestSuite, as encoding/xml writes an empty
+// properties element for an empty properties>property slice.
+type junitProperties struct {
+	Property []junitProperty `xml:"property"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message  string `xml:"message,attr,omitempty"`
+	Contents string `xml:",cdata"`
+}
+
+// writeJUnit writes pkgs as a JUnit XML report, with one test suite per package and
+// one test case per test. Test cases are attributed to a file, relative to the module
+// root, when it can be derived from the test output. Quarantined failures are reported
+// as skipped.
+func writeJUnit(w io.Writer, pkgs parse.Packages, opts reportOptions) error {
+	return writeXML(w, junitReport(pkgs, false, opts))
+}
+
+// writeCodecov writes pkgs as a JUnit XML report for Codecov test analytics. Unlike
+// writeJUnit, the failed run of a flaky test is reported as a separate test case
+// next to the passing rerun, so that Codecov can detect the flake.
+func writeCodecov(w io.Writer, pkgs parse.Packages, opts reportOptions) error {
+	return writeXML(w, junitReport(pkgs, true, opts))
+}
+
+func junitReport(pkgs parse.Packages, previous bool, opts reportOptions) junitTestSuites {
+	mod := readModulePath("go.mod")
+
+	var report junitTestSuites
+	var total float64
+	for _, name := range sortedPackageNames(pkgs) {
+		pkg := pkgs[name]
+
+		suite := junitTestSuite{
+			Name: name,
+			Time: junitSeconds(pkg.Summary.Elapsed),
+		}
+		if len(opts.labels) > 0 {
+			suite.Properties = &junitProperties{}
+			for _, l := range opts.labels {
+				suite.Properties.Property = append(suite.Properties.Property, junitProperty{Name: l.key, Value: l.value})
+			}
+		}
+		if !pkg.Started.IsZero() {
+			suite.Timestamp = pkg.Started.UTC().Format(time.RFC3339)
+		}
+		total += pkg.Summary.Elapsed
+
+		if pkg.HasPanic {
+			var out strings.Builder
+			for _, e := range pkg.PanicEvents {
+				out.WriteString(e.Output)
+			}
+			testName := pkg.Summary.Test
+			if testName == "" {
+				testName = "panic"
+			}
+			suite.Cases = append(suite.Cases, junitTestCase{
+				ClassName: name,
+				Name:      testName,
+				Time:      junitSeconds(0),
+				Failure:   &junitMessage{Message: "panic", Contents: xmlSafe(out.String())},
+			})
+		}
+
+		for _, t := range pkg.Tests {
+			if t.Name == "" || pkg.HasPanic {
+				continue
+			}
+			if previous && t.Flaky && len(t.Previous) > 0 {
+				suite.Cases = append(suite.Cases, junitCase(mod, &parse.Test{
+					Name:    t.Name,
+					Package: t.Package,
+					Events:  t.Previous,
+				}))
+			}
+			tc := junitCase(mod, t)
+			if tc.Failure != nil && opts.quarantine.Excused(pkg, t) {
+				tc.Failure, tc.Skipped = nil, &junitMessage{Message: xmlSafe(quarantinedMessage(t)), Contents: tc.Failure.Contents}
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+
+		for _, tc := range suite.Cases {
+			suite.Tests++
+			if tc.Failure != nil {
+				suite.Failures++
+			}
+			if tc.Skipped != nil {
+				suite.Skipped++
+			}
+		}
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Skipped += suite.Skipped
+		report.Suites = append(report.Suites, suite)
+	}
+	report.Time = junitSeconds(total)
+
+	return report
+}
+
+func junitCase(mod string, t *parse.Test) junitTestCase {
+	tc := junitTestCase{
+		ClassName: t.Package,
+		Name:      t.Name,
+		Time:      junitSeconds(t.Elapsed()),
+	}
+	switch t.Status() {
+	case parse.ActionFail:
+		tc.Failure = &junitMessage{Message: xmlSafe(t.Message()), Contents: xmlSafe(t.Stack())}
+	case parse.ActionSkip:
+		tc.Skipped = &junitMessage{Message: xmlSafe(t.Message())}
+	}
+	if loc, ok := t.Location(); ok {
+		tc.File = sourceFile(mod, t.Package, loc.File)
+	}
+	return tc
+}
+
+// writeXML writes v as an indented XML document.
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// xmlSafe replaces characters that are not allowed in XML documents, such as the
+// escape character of terminal color codes.
+func xmlSafe(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t' || r == '\n' || r == '\r':
+			return r
+		case r < 0x20, r == 0xFFFE, r == 0xFFFF:
+			return '\uFFFD'
+		}
+		return r
+	}, s)
+}
+
+func junitSeconds(f float64) string {
+	return strconv.FormatFloat(f, 'f', 3, 64)
+}
+
+// readModulePath returns the module path declared in the named go.mod file, or an
+// empty string if it cannot be read.
+func readModulePath(name string) string {
+	f, err := os.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// sourceFile returns the path of file, as referenced in the output of a test in package
+// pkg, relative to the root of module mod. Absolute paths and packages outside of mod
+// are returned unchanged.
+func sourceFile(mod, pkg, file string) string {
+	if path.IsAbs(file) || strings.Contains(file, "/") || mod == "" {
+		return file
+	}
+	if pkg == mod {
+		return file
+	}
+	if strings.HasPrefix(pkg, mod+"/") {
+		return path.Join(strings.TrimPrefix(pkg, mod+"/"), file)
+	}
+	return file
+}
+
+// writeFile creates the named file and writes to it using fn.
+func writeFile(name string, fn func(w io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := fn(f); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// runLabel is metadata attached to the run with -label, such as the branch or commit.
+type runLabel struct {
+	key, value string
+}
+
+// runLabels implements flag.Value for the repeatable -label flag. Labels are kept in
+// the order given.
+type runLabels []runLabel
+
+func (l *runLabels) String() string {
+	var s []string
+	for _, label := range *l {
+		s = append(s, label.key+"="+label.value)
+	}
+	return strings.Join(s, ",")
+}
+
+func (l *runLabels) Set(value string) error {
+	kv := strings.SplitN(value, "=", 2)
+	if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+		return fmt.Errorf("invalid label %q: want key=value", value)
+	}
+	*l = append(*l, runLabel{key: strings.TrimSpace(kv[0]), value: kv[1]})
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// hyperlink wraps text in an OSC 8 terminal hyperlink to url.
+func hyperlink(url, text string) string {
+	return "\x1b]8;;" + url + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// linkify wraps the source locations in s, as printed by tests in package pkg, in
+// terminal hyperlinks built from the -links template. Locations that cannot be resolved
+// to a file are left as they are.
+func (w *consoleWriter) linkify(s, pkg string) string {
+	if w.Links == "" || w.linkRoot == "" {
+		return s
+	}
+	root := w.linkRoot
+	dir := packageDir(w.linkModule, pkg)
+
+	return parse.ReplaceLocations(s, func(loc parse.Location) string {
+		text := loc.String()
+
+		file := filepath.FromSlash(loc.File)
+		if !filepath.IsAbs(file) {
+			if dir == "" || strings.ContainsRune(loc.File, '/') {
+				return text
+			}
+			file = filepath.Join(root, dir, file)
+		}
+		rel, err := filepath.Rel(root, file)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			rel = file
+		}
+
+		return hyperlink(expandLink(w.Links, file, filepath.ToSlash(rel), loc.Line), text)
+	})
+}
+
+// expandLink expands the -links template: %p is the absolute path of the file, %r the
+// path relative to the module root, %l the line and %% a literal percent sign.
+func expandLink(tmpl, abs, rel string, line int) string {
+	return strings.NewReplacer(
+		"%p", filepath.ToSlash(abs),
+		"%r", rel,
+		"%l", strconv.Itoa(line),
+		"%%", "%",
+	).Replace(tmpl)
+}
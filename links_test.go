@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestLinkify(t *testing.T) {
+
+	t.Parallel()
+
+	w := &consoleWriter{
+		Links:      "vscode://file%p:%l",
+		linkRoot:   "/src/mod",
+		linkModule: "example.com/mod",
+	}
+
+	tt := []struct {
+		s, pkg string
+		want   string
+	}{
+		// 0
+		{"    a_test.go:12: boom\n", "example.com/mod/pkg",
+			"    " + hyperlink("vscode://file/src/mod/pkg/a_test.go:12", "a_test.go:12") + ": boom\n"},
+		// 1, outside of the module
+		{"    a_test.go:12: boom\n", "example.com/other", "    a_test.go:12: boom\n"},
+		// 2
+		{"/src/mod/b.go:3 +0x1d\n", "example.com/mod",
+			hyperlink("vscode://file/src/mod/b.go:3", "/src/mod/b.go:3") + " +0x1d\n"},
+	}
+
+	for i, test := range tt {
+		if got := w.linkify(test.s, test.pkg); got != test.want {
+			t.Errorf("%d: got %q, want %q", i, got, test.want)
+		}
+	}
+
+	// Without a resolved working directory, output is left as it is.
+	if got := (&consoleWriter{Links: w.Links}).linkify(tt[0].s, tt[0].pkg); got != tt[0].s {
+		t.Errorf("got %q without a working directory", got)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+	"github.com/olekukonko/tablewriter"
+)
+
+// parseLowCoverage parses a -low-coverage threshold, a percentage such as "60" or "60%".
+// An empty value disables the report and returns -1.
+func parseLowCoverage(s string) (float64, error) {
+	if s == "" {
+		return -1, nil
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(s, "%")), 64)
+	if err != nil || f < 0 || f > 100 {
+		return 0, fmt.Errorf("invalid -low-coverage %q: must be a percentage", s)
+	}
+	return f, nil
+}
+
+// LowCoverageTable prints the packages run with -cover whose coverage is 0% or below
+// threshold, lowest first.
+func (w *consoleWriter) LowCoverageTable(pkgs parse.Packages, threshold float64) {
+	var low []string
+	for _, name := range sortedPackageNames(pkgs) {
+		pkg := pkgs[name]
+		if pkg.Cover && !pkg.HasPanic && (pkg.Coverage == 0 || pkg.Coverage < threshold) {
+			low = append(low, name)
+		}
+	}
+	if len(low) == 0 {
+		return
+	}
+	sort.SliceStable(low, func(i, j int) bool {
+		return pkgs[low[i]].Coverage < pkgs[low[j]].Coverage
+	})
+
+	s := fmt.Sprintf("\nLow coverage: %d package(s) below %.1f%% or uncovered", len(low), threshold)
+	fmt.Fprintf(w.Output, "%s\n\n", colorize(s, cYellow, w.Color))
+
+	tbl := tablewriter.NewWriter(w.Output)
+
+	tbl.SetHeader([]string{
+		"Package",
+		"Cover",
+	})
+
+	tbl.SetAutoWrapText(false)
+
+	for _, name := range low {
+		pkg := pkgs[name]
+		cover := fmt.Sprintf("%.1f%%", pkg.Coverage)
+		if *coverBarPtr {
+			cover = coverageBar(pkg.Coverage) + " " + cover
+		}
+		tbl.Append([]string{
+			name,
+			colorize(cover, coverageColor(pkg.Coverage), w.Color),
+		})
+	}
+
+	tbl.Render()
+}
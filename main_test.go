@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+func TestSummaryTableWallTime(t *testing.T) {
+
+	defer func(wallTime bool) { *wallTimePtr = wallTime }(*wallTimePtr)
+
+	f, err := os.Open("parse/testdata/parallel/input01.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	pkgs, err := parse.Process(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		wallTime bool
+		want     []string
+		wantNot  []string
+	}{
+		// 0
+		{false, []string{"ELAPSED", "4.00s"}, []string{"WALL", "TEST TIME"}},
+		// 1
+		{true, []string{"ELAPSED", "WALL", "TEST TIME", "4.00s"}, nil},
+	}
+
+	for i, test := range tt {
+		*wallTimePtr = test.wallTime
+		var buf bytes.Buffer
+		w := &consoleWriter{Output: &buf}
+		w.SummaryTable(pkgs, false, true)
+		got := buf.String()
+		for _, s := range test.want {
+			if !strings.Contains(got, s) {
+				t.Errorf("%d: missing %q in\n%s", i, s, got)
+			}
+		}
+		for _, s := range test.wantNot {
+			if strings.Contains(got, s) {
+				t.Errorf("%d: unexpected %q in\n%s", i, s, got)
+			}
+		}
+	}
+}
+
+func TestRunMatrix(t *testing.T) {
+
+	defer func(detailed bool) { *exitCodesPtr = detailed }(*exitCodesPtr)
+	*exitCodesPtr = true
+
+	tt := []struct {
+		inputs []string
+		want   int
+	}{
+		// 0
+		{[]string{"parse/testdata/cached_test.json:linux", "parse/testdata/cover_test.json:darwin"}, 0},
+		// 1, a failure on one platform
+		{[]string{"parse/testdata/cached_test.json:linux", "parse/testdata/cover/input01.json:darwin"}, exitTestFailure},
+		// 2, a data race on one platform
+		{[]string{"parse/testdata/cached_test.json:linux", "parse/testdata/race/input01.json:darwin"}, exitRace},
+		// 3
+		{[]string{"parse/testdata/cached_test.json:linux", "parse/testdata/missing.json:darwin"}, exitTparseError},
+	}
+
+	for i, test := range tt {
+		var inputs platformInputs
+		for _, in := range test.inputs {
+			inputs.Set(in)
+		}
+		if got := runMatrix(inputs); got != test.want {
+			t.Errorf("%d: got exit code %d, want %d", i, got, test.want)
+		}
+	}
+
+	// With -fail-on-race=false, a data race fails the run as its test failures do.
+	defer func(fail bool) { *failOnRacePtr = fail }(*failOnRacePtr)
+	*failOnRacePtr = false
+
+	var inputs platformInputs
+	inputs.Set("parse/testdata/cached_test.json:linux")
+	inputs.Set("parse/testdata/race/input01.json:darwin")
+	if got := runMatrix(inputs); got != exitTestFailure {
+		t.Errorf("got exit code %d without -fail-on-race, want %d", got, exitTestFailure)
+	}
+}
+
+func TestPlatformInputsSet(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		value, path, label string
+	}{
+		// 0
+		{"linux.json:linux", "linux.json", "linux"},
+		// 1, the label from the file name
+		{"results/windows-amd64.json", "results/windows-amd64.json", "windows-amd64"},
+		// 2, a Windows path
+		{"C:/results/darwin.json", "C:/results/darwin.json", "darwin"},
+	}
+
+	for i, test := range tt {
+		var inputs platformInputs
+		if err := inputs.Set(test.value); err != nil {
+			t.Fatal(err)
+		}
+		if got := inputs[0]; got.path != test.path || got.label != test.label {
+			t.Errorf("%d: got %s:%s, want %s:%s", i, got.path, got.label, test.path, test.label)
+		}
+	}
+}
+
+func TestConvertReader(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		input string
+		text  bool
+	}{
+		// 0
+		{`{"Action":"run","Package":"a","Test":"TestA"}` + "\n", false},
+		// 1
+		{"=== RUN   TestA\n--- PASS: TestA (0.00s)\nPASS\nok  \ta\t0.01s\n", true},
+		// 2, build output and logs before the first test
+		{"go: downloading example.com/b v1.0.0\n# a\nTestMain setup\n=== RUN   TestA\n--- PASS: TestA (0.00s)\nok  \ta\t0.01s\n", true},
+		// 3, logs before JSON events
+		{"go: downloading example.com/b v1.0.0\n" + `{"Action":"run","Package":"a","Test":"TestA"}` + "\n", false},
+		// 4
+		{"not test output\n", false},
+		// 5
+		{"", false},
+	}
+
+	for i, test := range tt {
+		r := convertReader(ioutil.NopCloser(strings.NewReader(test.input)))
+		got, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if !test.text {
+			if string(got) != test.input {
+				t.Errorf("%d: got %q, want the input unchanged", i, got)
+			}
+			continue
+		}
+		pkgs, err := parse.Process(bytes.NewReader(got))
+		if err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+		if pkg := pkgs["a"]; pkg == nil || len(pkg.TestsByAction(parse.ActionPass)) != 1 {
+			t.Errorf("%d: got packages %v, want a with one passed test", i, pkgs)
+		}
+	}
+}
+
+func TestReportFormats(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		fixture  string
+		format   string
+		exitCode int
+		labels   runLabels
+		want     []string
+		wantNot  []string
+	}{
+		// 0
+		{
+			"cover_test.json", "markdown", 0, nil,
+			[]string{"### ✅ tparse: PASS\n", "167 passed, 0 failed, 1 skipped in 3 packages", "| PASS | (cached) | bytes | 86.7% | 123 | 0 | 0 |"},
+			[]string{"#### FAIL"},
+		},
+		// 1
+		{
+			"cover_test.json", "badge", 0, nil,
+			[]string{`"message": "71.8%"`, `"color": "yellow"`},
+			nil,
+		},
+		// 2
+		{
+			"cover_test.json", "badge-svg", 0, nil,
+			[]string{`aria-label="coverage: 71.8%"`, `fill="#dfb317"`},
+			nil,
+		},
+		// 3
+		{
+			"cover_test.json", "codecov", 0, nil,
+			[]string{`<testsuites tests="168" failures="0" skipped="1"`, `<testsuite name="bytes" tests="123"`},
+			[]string{"<properties>"},
+		},
+		// 4
+		{
+			"cover/input01.json", "junit", 1, nil,
+			[]string{`<testcase classname="example.com/user" name="TestUser" file="user_test.go"`, `<failure message="unexpected name">`},
+			[]string{"<properties>"},
+		},
+		// 5
+		{
+			"cover/input01.json", "sonar", 1, nil,
+			[]string{`<file path="user_test.go">`, `<testCase name="TestUser" duration="0">`, `<failure message="store.go:6: closed">`},
+			nil,
+		},
+		// 6
+		{
+			"cover/input01.json", "failures", 1, nil,
+			[]string{"=== FAIL: example.com/user TestUser (0.00s)\n    user_test.go:42: unexpected name\n"},
+			nil,
+		},
+		// 7
+		{
+			"cover/input01.json", "buildkite", 1, nil,
+			[]string{"**Go tests failed**: 0 passed, 2 failed, 0 skipped in 1 packages", "<code>TestUser</code> in <code>example.com/user</code>"},
+			nil,
+		},
+		// 8
+		{
+			"panic/input01.json", "markdown", 1, nil,
+			[]string{"| PANIC | 0.00s | github.com/mfridman/tparse/parse | -- |", "panic in <code>TestSingleFailStack/input04</code>"},
+			nil,
+		},
+		// 9
+		{
+			"panic/input01.json", "sonar", 1, nil,
+			[]string{`<testCase name="TestSingleFailStack/input04" duration="0">`, `<error message="panic">`},
+			nil,
+		},
+		// 10
+		{
+			"panic/input01.json", "failures", 1, nil,
+			[]string{"=== PANIC: github.com/mfridman/tparse/parse TestSingleFailStack/input04\npanic: interface conversion"},
+			nil,
+		},
+		// 11
+		{
+			"cached_test.json", "json", 0, runLabels{{key: "branch", value: "main"}, {key: "commit", value: "abc123"}, {key: "run", value: "7"}},
+			[]string{`"branch": "main"`, `"commit": "abc123"`, `"run": "7"`, `"status": "pass"`, `"passed": 302`, `"package": "fmt"`, `"cached": true`, `"labels": {`},
+			nil,
+		},
+		// 12
+		{
+			"cached_test.json", "junit", 0, runLabels{{key: "branch", value: "main"}},
+			[]string{`<properties>`, `<property name="branch" value="main"></property>`, `<skipped message="skipping; GOMAXPROCS&gt;1">`, `timestamp="2018-11-25T03:27:21Z"`},
+			// The time package ran from 03:27:21 to 03:27:28; suites are stamped with their start.
+			[]string{`timestamp="2018-11-25T03:27:28Z"`},
+		},
+	}
+
+	for i, test := range tt {
+		t.Run(fmt.Sprintf("%s_%d", test.format, i), func(t *testing.T) {
+			f, err := os.Open(filepath.Join("parse/testdata", test.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			pkgs, err := parse.Process(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := reportFormats[test.format](&buf, pkgs, test.exitCode, reportOptions{labels: test.labels}); err != nil {
+				t.Fatal(err)
+			}
+			got := buf.String()
+			for _, s := range test.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("missing %q in\n%s", s, got)
+				}
+			}
+			for _, s := range test.wantNot {
+				if strings.Contains(got, s) {
+					t.Errorf("unexpected %q in\n%s", s, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// markdownMarker identifies markdown summaries written by tparse, so that an existing
+// comment can be found and updated instead of creating a new one.
+const markdownMarker = "<!-- tparse -->"
+
+// writeMarkdown renders a summary of pkgs as GitHub flavored markdown: an overall
+// result, a package table and the output of failed tests. Quarantined failures are
+// listed apart, without their output.
+func writeMarkdown(w io.Writer, pkgs parse.Packages, exitCode int, opts reportOptions) error {
+	var sb strings.Builder
+
+	sb.WriteString(markdownMarker + "\n")
+	if exitCode == 0 {
+		sb.WriteString("### ✅ tparse: PASS\n\n")
+	} else {
+		sb.WriteString("### ❌ tparse: FAIL\n\n")
+	}
+
+	t := pkgs.Totals()
+	fmt.Fprintf(&sb, "%d passed, %d failed, %d skipped in %d packages\n\n",
+		t.Passed, t.Failed, t.Skipped, t.Packages)
+
+	if len(opts.labels) > 0 {
+		for i, l := range opts.labels {
+			if i > 0 {
+				sb.WriteString(" · ")
+			}
+			fmt.Fprintf(&sb, "%s: %s", markdownCell(l.key), markdownCode(l.value))
+		}
+		sb.WriteString("\n\n")
+	}
+
+	names := sortedPackageNames(pkgs)
+
+	sb.WriteString("| " + strings.Join(summaryHeaders, " | ") + " |\n")
+	sb.WriteString("|:------:|--------:|:--------|------:|-----:|-----:|-----:|\n")
+	for _, row := range summaryRows(pkgs) {
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %s | %s |\n",
+			row.Status, row.Elapsed, markdownCell(row.Package), row.Cover, row.Pass, row.Fail, row.Skip)
+	}
+
+	for _, name := range names {
+		pkg := pkgs[name]
+
+		if pkg.HasPanic {
+			fmt.Fprintf(&sb, "\n#### PANIC: %s\n", name)
+			var out strings.Builder
+			for _, e := range pkg.PanicEvents {
+				out.WriteString(e.Output)
+			}
+			summary := "panic"
+			if pkg.Summary.Test != "" {
+				summary = fmt.Sprintf("panic in <code>%s</code>", html.EscapeString(pkg.Summary.Test))
+			}
+			writeMarkdownDetails(&sb, summary, out.String())
+			continue
+		}
+
+		failed := opts.failed(pkg)
+		if len(failed) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "\n#### FAIL: %s\n", name)
+		for _, t := range failed {
+			summary := fmt.Sprintf("<code>%s</code> (%.2fs)", html.EscapeString(t.Name), t.Elapsed())
+			writeMarkdownDetails(&sb, summary, failureOutput(t))
+		}
+	}
+
+	var quarantined []string
+	for _, name := range names {
+		pkg := pkgs[name]
+		if pkg.HasPanic {
+			continue
+		}
+		for _, t := range opts.quarantined(pkg) {
+			quarantined = append(quarantined, fmt.Sprintf("- <code>%s</code> in <code>%s</code>\n",
+				html.EscapeString(t.Name), html.EscapeString(name)))
+		}
+	}
+	if len(quarantined) > 0 {
+		sb.WriteString("\n#### QUARANTINED FAILURES\n\n")
+		sb.WriteString(strings.Join(quarantined, ""))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// summaryHeaders are the columns of the package table of the markdown and HTML
+// summaries.
+var summaryHeaders = []string{"Status", "Elapsed", "Package", "Cover", "Pass", "Fail", "Skip"}
+
+// summaryRow is a row of the package table of the markdown and HTML summaries.
+type summaryRow struct {
+	Status, Elapsed, Package, Cover string
+	Pass, Fail, Skip                string
+}
+
+// summaryRows returns the rows of the package table, sorted by package.
+func summaryRows(pkgs parse.Packages) []summaryRow {
+	var rows []summaryRow
+	for _, name := range sortedPackageNames(pkgs) {
+		pkg := pkgs[name]
+
+		elapsed := strconv.FormatFloat(pkg.Summary.Elapsed, 'f', 2, 64) + "s"
+		if pkg.Cached {
+			elapsed = "(cached)"
+		}
+
+		switch {
+		case pkg.HasPanic:
+			rows = append(rows, summaryRow{"PANIC", elapsed, name, "--", "--", "--", "--"})
+		case pkg.NoTestFiles:
+			rows = append(rows, summaryRow{"NOTEST", elapsed, name + " [no test files]", "--", "--", "--", "--"})
+		default:
+			rows = append(rows, summaryRow{
+				Status:  strings.ToUpper(pkg.Summary.Action.String()),
+				Elapsed: elapsed,
+				Package: name,
+				Cover:   fmt.Sprintf("%.1f%%", pkg.Coverage),
+				Pass:    strconv.Itoa(len(pkg.TestsByAction(parse.ActionPass))),
+				Fail:    strconv.Itoa(len(pkg.TestsByAction(parse.ActionFail))),
+				Skip:    strconv.Itoa(len(pkg.TestsByAction(parse.ActionSkip))),
+			})
+		}
+	}
+	return rows
+}
+
+// writeMarkdownDetails writes s as a fenced code block in a collapsible section, with
+// summary as its always visible HTML summary line.
+func writeMarkdownDetails(sb *strings.Builder, summary, s string) {
+	fmt.Fprintf(sb, "\n<details>\n<summary>%s</summary>\n\n", summary)
+	writeMarkdownCode(sb, s)
+	sb.WriteString("\n</details>\n")
+}
+
+// failureOutput returns the output to show for a failed test: its log output, or the
+// report if it has none.
+func failureOutput(t *parse.Test) string {
+	if out := t.Output(); out != "" {
+		return out
+	}
+	return t.Stack()
+}
+
+// writeMarkdownCode writes s as a fenced code block.
+func writeMarkdownCode(sb *strings.Builder, s string) {
+	// Use a fence longer than any backtick run within s.
+	fence := "```"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	sb.WriteString(fence + "\n")
+	sb.WriteString(s)
+	if !strings.HasSuffix(s, "\n") {
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fence + "\n")
+}
+
+// markdownCode returns s as an inline code span.
+func markdownCode(s string) string {
+	// Like writeMarkdownCode, delimit with more backticks than any run within s,
+	// padded with spaces if s starts or ends with a backtick.
+	s = strings.Replace(s, "\n", " ", -1)
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// markdownCell escapes characters that would break a markdown table cell or emphasis.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "*", `\*`, "_", `\_`, "\n", " ").Replace(s)
+}
+
+// sortedPackageNames returns the package names in pkgs, sorted.
+func sortedPackageNames(pkgs parse.Packages) []string {
+	names := make([]string, 0, len(pkgs))
+	for name := range pkgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+	"github.com/olekukonko/tablewriter"
+)
+
+// platformInput is a go test -json output file and the platform it was recorded on.
+type platformInput struct {
+	path, label string
+}
+
+// platformInputs implements flag.Value for the repeatable -input flag.
+type platformInputs []platformInput
+
+func (p *platformInputs) String() string {
+	var s []string
+	for _, in := range *p {
+		s = append(s, in.path+":"+in.label)
+	}
+	return strings.Join(s, ",")
+}
+
+func (p *platformInputs) Set(value string) error {
+	in := platformInput{path: value}
+	if i := strings.LastIndex(value, ":"); i > 0 && !strings.ContainsAny(value[i+1:], `/\`) {
+		in.path, in.label = value[:i], value[i+1:]
+	}
+	if in.label == "" {
+		in.label = strings.TrimSuffix(filepath.Base(in.path), filepath.Ext(in.path))
+	}
+	*p = append(*p, in)
+	return nil
+}
+
+// runMatrix parses each -input as the results of one platform and prints a summary
+// per platform and a matrix of the tests that failed on any of them. It returns the
+// exit code, non-zero if any platform failed.
+func runMatrix(inputs platformInputs) int {
+	redactor, err := newRedactor()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tparse error: %v\n", err)
+		return failureCode(exitTparseError)
+	}
+
+	var runs []parse.Packages
+	var exitCode int
+	for _, in := range inputs {
+		f, err := os.Open(in.path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tparse error: %v\n", err)
+			return failureCode(exitTparseError)
+		}
+		pkgs, err := parse.Process(convertReader(f), parse.WithRedactor(redactor))
+		f.Close()
+		if err != nil && err != parse.ErrRaceDetected {
+			fmt.Fprintf(os.Stderr, "tparse error: %s: %v\n", in.path, err)
+			return failureCode(exitTparseError)
+		}
+		if pkgs.ExitCode() != 0 && exitCode == 0 {
+			exitCode = 1
+		}
+		// As for a single run, a data race fails the run with its own exit code.
+		if err == parse.ErrRaceDetected && *failOnRacePtr {
+			exitCode = failureCode(exitRace)
+		}
+		runs = append(runs, pkgs)
+	}
+
+	w := newWriter(exitCode)
+	w.PlatformTable(inputs, runs)
+	w.MatrixTable(inputs, parse.Matrix(runs), *smallScreenPtr)
+	return exitCode
+}
+
+// PlatformTable prints the test counts of each platform.
+func (w *consoleWriter) PlatformTable(inputs platformInputs, runs []parse.Packages) {
+	tbl := tablewriter.NewWriter(w.Output)
+
+	tbl.SetHeader([]string{
+		"Status",
+		"Platform",
+		"Packages",
+		"Pass",
+		"Fail",
+		"Skip",
+	})
+
+	tbl.SetAutoWrapText(false)
+
+	for i, pkgs := range runs {
+		action := parse.ActionPass
+		if pkgs.ExitCode() != 0 {
+			action = parse.ActionFail
+		}
+		totals := pkgs.Totals()
+		tbl.Append([]string{
+			withColor(action, w.Color),
+			inputs[i].label,
+			strconv.Itoa(totals.Packages),
+			strconv.Itoa(totals.Passed),
+			strconv.Itoa(totals.Failed),
+			strconv.Itoa(totals.Skipped),
+		})
+	}
+
+	fmt.Fprintf(w.Output, "\n")
+	tbl.Render()
+}
+
+// MatrixTable prints the status of each failed test on every platform. Tests that
+// passed on some platforms and failed on others are marked as platform-specific.
+func (w *consoleWriter) MatrixTable(inputs platformInputs, rows []*parse.MatrixRow, trim bool) {
+	if len(rows) == 0 {
+		return
+	}
+
+	tbl := tablewriter.NewWriter(w.Output)
+
+	header := []string{"Test", "Package"}
+	for _, in := range inputs {
+		header = append(header, in.label)
+	}
+	header = append(header, "Scope")
+	tbl.SetHeader(header)
+
+	tbl.SetAutoWrapText(false)
+
+	for _, r := range rows {
+		row := []string{
+			testName(r.Name, trim),
+			filepath.Base(r.Package),
+		}
+		for _, s := range r.Status {
+			if s == "" {
+				row = append(row, "--")
+				continue
+			}
+			row = append(row, withColor(s, w.Color))
+		}
+		scope := "consistent"
+		if r.PlatformSpecific() {
+			scope = colorize("platform-specific", cYellow, w.Color)
+		}
+		tbl.Append(append(row, scope))
+	}
+
+	fmt.Fprintf(w.Output, "\n")
+	tbl.Render()
+}
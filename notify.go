@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// notificationText returns the title and body of the completion notification.
+func notificationText(pkgs parse.Packages, exitCode int) (string, string) {
+	title := "tparse: PASS"
+	if exitCode != 0 {
+		title = "tparse: FAIL"
+	}
+
+	t := pkgs.Totals()
+	body := fmt.Sprintf("%d passed, %d failed, %d skipped in %d packages",
+		t.Passed, t.Failed, t.Skipped, t.Packages)
+
+	return title, body
+}
+
+// notify fires a desktop notification using the notification tool of the current
+// platform: osascript on macOS, notify-send on Linux and PowerShell on Windows.
+func notify(title, body string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptQuote(body), appleScriptQuote(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		script := fmt.Sprintf(`Add-Type -AssemblyName System.Windows.Forms
+$n = New-Object System.Windows.Forms.NotifyIcon
+$n.Icon = [System.Drawing.SystemIcons]::Information
+$n.Visible = $true
+$n.ShowBalloonTip(10000, %s, %s, [System.Windows.Forms.ToolTipIcon]::None)
+Start-Sleep -Seconds 1`, powerShellQuote(title), powerShellQuote(body))
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	default:
+		cmd = exec.Command("notify-send", title, body)
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if s := strings.TrimSpace(string(out)); s != "" {
+			return fmt.Errorf("%v: %s", err, s)
+		}
+		return err
+	}
+	return nil
+}
+
+func appleScriptQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func powerShellQuote(s string) string {
+	return `'` + strings.Replace(s, `'`, `''`, -1) + `'`
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// detectedPanic is a panic found in the output of a package, for -fail-on-panic.
+type detectedPanic struct {
+	pkg, test, line string
+}
+
+// findPanics returns the panics in all packages, sorted by package: those that crashed
+// a test binary, and those recovered and logged by tests.
+func findPanics(pkgs parse.Packages) []detectedPanic {
+	var panics []detectedPanic
+	for _, name := range sortedPackageNames(pkgs) {
+		pkg := pkgs[name]
+		if pkg.HasPanic {
+			p := detectedPanic{pkg: name, test: pkg.Summary.Test}
+			for _, e := range pkg.PanicEvents {
+				if e.IsPanic() {
+					p.line = strings.TrimSpace(e.Output)
+					break
+				}
+			}
+			panics = append(panics, p)
+		}
+		for _, t := range pkg.Tests {
+			for _, line := range t.Panics() {
+				panics = append(panics, detectedPanic{pkg: name, test: t.Name, line: line})
+			}
+		}
+	}
+	return panics
+}
+
+// PanicBanner prints a prominent banner listing the panics found with -fail-on-panic.
+func (w *consoleWriter) PanicBanner(panics []detectedPanic) {
+	if len(panics) == 0 {
+		return
+	}
+
+	s := fmt.Sprintf("\n!!! %d PANIC(S) DETECTED !!!", len(panics))
+	fmt.Fprintf(w.Output, "%s\n\n", colorize(s, cRed, w.Color))
+	for _, p := range panics {
+		where := p.pkg
+		if p.test != "" {
+			where += " " + p.test
+		}
+		fmt.Fprintf(w.Output, "\t%s: %s\n", where, w.linkify(p.line, p.pkg))
+	}
+}
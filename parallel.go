@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+	"github.com/olekukonko/tablewriter"
+)
+
+// timelineWidth is the number of cells in a parallelism timeline.
+const timelineWidth = 20
+
+var sparks = []string{"▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
+
+// sparkline renders values as block characters scaled to max, with a space for zero.
+func sparkline(values []float64, max float64) string {
+	var sb strings.Builder
+	for _, v := range values {
+		if v <= 0 || max <= 0 {
+			sb.WriteString(" ")
+			continue
+		}
+		i := int(v/max*float64(len(sparks)) + 0.5)
+		if i < 1 {
+			i = 1
+		}
+		if i > len(sparks) {
+			i = len(sparks)
+		}
+		sb.WriteString(sparks[i-1])
+	}
+	return sb.String()
+}
+
+// ParallelismTable prints, for each package, its wall time, the time tests spent
+// running, the mean and peak number of tests running at once and a timeline of the
+// number running, to help tune -parallel and spot tests that serialize a package.
+func (w *consoleWriter) ParallelismTable(pkgs parse.Packages) {
+	tbl := tablewriter.NewWriter(w.Output)
+	tbl.SetHeader([]string{
+		"Package",   // 0
+		"Wall",      // 1
+		"Test Time", // 2
+		"Mean",      // 3
+		"Peak",      // 4
+		"Timeline",  // 5
+	})
+
+	tbl.SetAutoWrapText(false)
+
+	names := make([]string, 0, len(pkgs))
+	for name, pkg := range pkgs {
+		if pkg.NoTestFiles || pkg.HasPanic || len(pkg.Tests) == 0 {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		par := pkgs[name].Parallelism(timelineWidth)
+		if par.Wall == 0 {
+			continue
+		}
+		tbl.Append([]string{
+			name,
+			strconv.FormatFloat(par.Wall, 'f', 2, 64) + "s",
+			strconv.FormatFloat(par.Busy, 'f', 2, 64) + "s",
+			strconv.FormatFloat(par.Mean(), 'f', 2, 64),
+			strconv.Itoa(par.Peak),
+			sparkline(par.Timeline, float64(par.Peak)),
+		})
+	}
+
+	if tbl.NumLines() > 0 {
+		fmt.Fprintf(w.Output, "\n")
+		tbl.Render()
+	}
+}
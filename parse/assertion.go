@@ -0,0 +1,116 @@
+package parse
+
+import (
+	"strings"
+)
+
+// Assertion is a failed testify assertion, parsed from a block of test output such as:
+//
+//	    user_test.go:42:
+//	        	Error Trace:	/src/user/user_test.go:42
+//	        	Error:      	Not equal:
+//	        	            	expected: "alice"
+//	        	            	actual  : "bob"
+//	        	Test:       	TestUser
+//	        	Messages:   	wrong name
+type Assertion struct {
+	// Location is the first frame of the error trace, typically the assertion call.
+	Location Location
+	// Error is the error message, e.g., "Not equal:", joined with any detail lines
+	// other than the compared values and their diff.
+	Error string
+	// Expected and Actual are the values of a failed comparison, if any.
+	Expected string
+	Actual   string
+	// Messages are the optional message arguments of the assertion.
+	Messages string
+}
+
+// Summary returns the error, values and messages of the assertion on a single line.
+func (a Assertion) Summary() string {
+	s := strings.TrimSuffix(a.Error, ":")
+	if a.Expected != "" && a.Actual != "" && !strings.Contains(a.Expected+a.Actual, "\n") {
+		s += " (expected " + a.Expected + ", actual " + a.Actual + ")"
+	}
+	if a.Messages != "" {
+		s += ": " + strings.Replace(a.Messages, "\n", " ", -1)
+	}
+	return s
+}
+
+// Assertions returns the testify assertions that failed in the test, in order of
+// appearance.
+func (t *Test) Assertions() []Assertion {
+	t.SortEvents()
+
+	var out strings.Builder
+	for _, e := range t.Events {
+		if e.Action == ActionOutput {
+			out.WriteString(e.Output)
+		}
+	}
+	return parseAssertions(out.String())
+}
+
+// parseAssertions returns the testify assertions found in test output.
+func parseAssertions(output string) []Assertion {
+	var assertions []Assertion
+
+	var (
+		current *Assertion
+		field   string
+	)
+	for _, line := range strings.Split(output, "\n") {
+		// Fields are indented, and separated from their label by tabs:
+		// "        \tError:      \tNot equal:". Continuation lines have an empty label.
+		line = strings.TrimLeft(line, " ")
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 || parts[0] != "" {
+			field = ""
+			continue
+		}
+		label, value := strings.TrimSpace(parts[1]), strings.TrimRight(parts[2], " ")
+
+		if label != "" {
+			field = strings.TrimSuffix(label, ":")
+			if field == "Error Trace" {
+				assertions = append(assertions, Assertion{})
+				current = &assertions[len(assertions)-1]
+			}
+		}
+		if current == nil {
+			continue
+		}
+
+		switch field {
+		case "Error Trace":
+			if current.Location.File == "" {
+				if locs := Locations(value); len(locs) > 0 {
+					current.Location = locs[0]
+				}
+			}
+		case "Error":
+			switch {
+			case label != "":
+				current.Error = value
+			case strings.HasPrefix(value, "expected:"):
+				current.Expected = strings.TrimSpace(strings.TrimPrefix(value, "expected:"))
+			case strings.HasPrefix(value, "actual"):
+				value = strings.TrimSpace(strings.TrimPrefix(value, "actual"))
+				current.Actual = strings.TrimSpace(strings.TrimPrefix(value, ":"))
+			case value == "Diff:":
+				field = "Diff"
+			case value != "" && current.Expected == "":
+				// Details such as the unexpected error of require.NoError.
+				current.Error += " " + strings.TrimSpace(value)
+			}
+		case "Messages":
+			if current.Messages != "" {
+				current.Messages += "\n"
+			}
+			current.Messages += value
+		}
+	}
+
+	return assertions
+}
@@ -0,0 +1,95 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAssertions(t *testing.T) {
+
+	t.Parallel()
+
+	// input01.json contains testify failures in TestUser and TestLoad, and a plain
+	// t.Error failure in TestPlain.
+	by, err := ioutil.ReadFile(filepath.Join("testdata", "assertion", "input01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := pkgs["example.com/user"]
+
+	tt := []struct {
+		test     string
+		want     []Assertion
+		location Location
+		message  string
+	}{
+		// 0
+		{
+			"TestUser",
+			[]Assertion{
+				{
+					Location: Location{File: "/src/user/user_test.go", Line: 42},
+					Error:    "Not equal:",
+					Expected: `"alice"`,
+					Actual:   `"bob"`,
+					Messages: "wrong name",
+				},
+				{
+					Location: Location{File: "/src/user/user_test.go", Line: 43},
+					Error:    "Should be true",
+				},
+			},
+			Location{File: "user_test.go", Line: 42},
+			`Not equal (expected "alice", actual "bob"): wrong name`,
+		},
+		// 1
+		{
+			"TestLoad",
+			[]Assertion{
+				{
+					Location: Location{File: "/src/user/load_test.go", Line: 17},
+					Error:    "Received unexpected error: open users.json: no such file or directory",
+				},
+			},
+			Location{File: "load_test.go", Line: 17},
+			"Received unexpected error: open users.json: no such file or directory",
+		},
+		// 2
+		{
+			"TestPlain",
+			nil,
+			Location{},
+			"",
+		},
+	}
+
+	for i, test := range tt {
+		t.Run(fmt.Sprintf("test_%d", i), func(t *testing.T) {
+			tc := pkg.GetTest(test.test)
+			if tc == nil {
+				t.Fatalf("test %s not found", test.test)
+			}
+			if got := tc.Assertions(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got assertions\n%+v\nwant\n%+v", got, test.want)
+			}
+			if test.want == nil {
+				return
+			}
+			if loc, _ := tc.Location(); loc != test.location {
+				t.Errorf("got location %v, want %v", loc, test.location)
+			}
+			if got := tc.Message(); got != test.message {
+				t.Errorf("got message %q, want %q", got, test.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package parse
+
+import (
+	"strings"
+)
+
+// attributor tracks the run state of tests within a single package, in order to
+// attribute interleaved output of parallel tests to the correct test.
+//
+// When tests call t.Parallel() go test interleaves their output, and test2json
+// occasionally files output lines under the wrong test, or under no test at all.
+type attributor struct {
+	// seen holds all tests that have started.
+	seen map[string]bool
+
+	// running holds tests that have started or continued, and not yet paused or finished.
+	running map[string]bool
+
+	// current is the test most recently reported as running, or the test named by
+	// the most recent "=== NAME" marker or "--- FAIL: " report line. Unattributed
+	// output belongs to it.
+	current string
+
+	// misfiled is the test that the most recent "=== NAME" marker was filed under, when
+	// it names another test, as by test2json before go1.20 that does not know these
+	// markers. Output filed under it belongs to current until the next marker.
+	misfiled string
+}
+
+func newAttributor() *attributor {
+	return &attributor{
+		seen:    make(map[string]bool),
+		running: make(map[string]bool),
+	}
+}
+
+// Attribute updates the run state from e, and corrects e.Test for output events
+// that were filed under the wrong test or no test.
+func (a *attributor) Attribute(e *Event) {
+	switch e.Action {
+	case ActionRun, ActionCont:
+		a.misfiled = ""
+		a.seen[e.Test] = true
+		a.running[e.Test] = true
+		a.current = e.Test
+		return
+	case ActionPause, ActionPass, ActionFail, ActionSkip:
+		if e.Test == "" {
+			return
+		}
+		a.misfiled = ""
+		delete(a.running, e.Test)
+		if a.current == e.Test {
+			a.current = a.onlyRunning()
+		}
+		return
+	case ActionOutput:
+	default:
+		return
+	}
+
+	// Since go1.20, go test prints "=== NAME  TestName" when the output switches between
+	// parallel tests, and the output following it belongs to that test. A marker
+	// without a name switches back to output of the package.
+	if name, ok := markerName(e.Output); ok && (name == "" || a.seen[name]) {
+		a.misfiled = ""
+		if e.Test != name {
+			a.misfiled = e.Test
+		}
+		e.Test = name
+		a.current = name
+		return
+	}
+
+	// A report line names the test it belongs to, and all indented lines following
+	// it belong to the same test.
+	if name, ok := reportName(e.Output); ok && a.seen[name] {
+		a.misfiled = ""
+		e.Test = name
+		a.current = name
+		return
+	}
+
+	if a.misfiled != "" && e.Test == a.misfiled {
+		e.Test = a.current
+		return
+	}
+	if e.Test == "" && a.current != "" && !e.PackageFraming() {
+		e.Test = a.current
+	}
+}
+
+// onlyRunning returns the name of the running test if exactly one test is running,
+// otherwise an empty string.
+func (a *attributor) onlyRunning() string {
+	if len(a.running) != 1 {
+		return ""
+	}
+	for name := range a.running {
+		return name
+	}
+	return ""
+}
+
+// markerName returns the test name from a "=== NAME" marker line, such as:
+// "=== NAME  TestCatch/catchAndRetrieve\n", which is empty for output of the package.
+func markerName(output string) (string, bool) {
+	if !strings.HasPrefix(output, "=== NAME") {
+		return "", false
+	}
+	s := strings.TrimPrefix(output, "=== NAME")
+	if s != "" && s[0] != ' ' && s[0] != '\n' {
+		return "", false
+	}
+	return strings.TrimSpace(s), true
+}
+
+// reportName returns the test name from a report line, such as:
+// "    --- FAIL: TestCatch/catchAndRetrieve (0.00s)\n"
+func reportName(output string) (string, bool) {
+	s := strings.TrimLeft(output, " \t")
+	for i := range reports {
+		if !strings.HasPrefix(s, reports[i]) {
+			continue
+		}
+		s = strings.TrimPrefix(s, reports[i])
+		if i := strings.Index(s, " ("); i > 0 {
+			return s[:i], true
+		}
+		return strings.TrimSpace(s), s != ""
+	}
+	return "", false
+}
@@ -0,0 +1,163 @@
+package parse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestAttribution(t *testing.T) {
+
+	t.Parallel()
+
+	// input01.json contains two parallel tests with interleaved output. The report line
+	// of TestA is filed under TestB and the failure messages of both tests have no test name.
+	by, err := ioutil.ReadFile(filepath.Join("testdata", "attribution", "input01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := pkgs["github.com/mfridman/tparse/tests"]
+
+	tt := []struct {
+		name, stack string
+	}{
+		{"TestA", "--- FAIL: TestA (0.00s)\n    a_test.go:10: boom A\n"},
+		{"TestB", "--- FAIL: TestB (0.00s)\n    b_test.go:20: boom B\n"},
+	}
+
+	for _, test := range tt {
+		tc := pkg.GetTest(test.name)
+		if tc == nil {
+			t.Fatalf("got no test %q", test.name)
+		}
+		if got := tc.Stack(); got != test.stack {
+			t.Errorf("got %s stack:\n%q\nwant:\n%q", test.name, got, test.stack)
+		}
+	}
+
+	if got := len(pkg.Tests); got != 2 {
+		t.Errorf("got %d tests, want 2", got)
+	}
+}
+
+func TestAttributionName(t *testing.T) {
+
+	t.Parallel()
+
+	// input02.json contains two parallel tests whose output switches between them with
+	// "=== NAME" markers, as printed since go1.20, converted by an older test2json that
+	// files the markers and the output following them under the wrong test or no test.
+	by, err := ioutil.ReadFile(filepath.Join("testdata", "attribution", "input02.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := pkgs["github.com/mfridman/tparse/tests"]
+
+	tt := []struct {
+		name, output string
+	}{
+		{"TestA", "    a_test.go:8: log from A\n    a_test.go:9: boom A\n"},
+		{"TestB", "    b_test.go:5: log from B\n    b_test.go:6: boom B\n"},
+	}
+
+	for _, test := range tt {
+		tc := pkg.GetTest(test.name)
+		if tc == nil {
+			t.Fatalf("got no test %q", test.name)
+		}
+		if got := tc.Output(); got != test.output {
+			t.Errorf("got %s output:\n%q\nwant:\n%q", test.name, got, test.output)
+		}
+	}
+
+	if got := len(pkg.Unattributed); got != 0 {
+		t.Errorf("got %d unattributed events, want 0", got)
+	}
+}
+
+func TestMarkerName(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		output, name string
+		ok           bool
+	}{
+		{"=== NAME  TestA\n", "TestA", true},
+		{"=== NAME  TestCatch/catchAndRetrieve\n", "TestCatch/catchAndRetrieve", true},
+		{"=== NAME\n", "", true},
+		{"=== NAMED TestA\n", "", false},
+		{"=== RUN   TestA\n", "", false},
+		{"    a_test.go:10: === NAME  TestA\n", "", false},
+	}
+
+	for _, test := range tt {
+		name, ok := markerName(test.output)
+		if name != test.name || ok != test.ok {
+			t.Errorf("%q: got (%q, %t), want (%q, %t)", test.output, name, ok, test.name, test.ok)
+		}
+	}
+}
+
+func TestReportName(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		output, name string
+		ok           bool
+	}{
+		{"--- FAIL: TestA (0.00s)\n", "TestA", true},
+		{"    --- PASS: TestCatch/catchAndRetrieve (0.01s)\n", "TestCatch/catchAndRetrieve", true},
+		{"--- SKIP: TestB\n", "TestB", true},
+		{"=== RUN   TestA\n", "", false},
+		{"    a_test.go:10: --- FAIL: TestA\n", "", false},
+	}
+
+	for _, test := range tt {
+		name, ok := reportName(test.output)
+		if name != test.name || ok != test.ok {
+			t.Errorf("%q: got (%q, %t), want (%q, %t)", test.output, name, ok, test.name, test.ok)
+		}
+	}
+}
+
+func TestUnattributed(t *testing.T) {
+
+	t.Parallel()
+
+	// input02.json contains 6 log lines emitted before any test runs.
+	by, err := ioutil.ReadFile(filepath.Join("testdata", "big", "input02.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := pkgs["github.com/astromail/rover/tests"]
+	if got := len(pkg.Unattributed); got != 6 {
+		for _, e := range pkg.Unattributed {
+			t.Logf("%q", e.Output)
+		}
+		t.Fatalf("got %d unattributed events, want 6", got)
+	}
+	if got := pkg.Unattributed[1].Output; got != "2018/10/28 00:06:54 Iterating file id: 0\n" {
+		t.Errorf("got unexpected unattributed output %q", got)
+	}
+}
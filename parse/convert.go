@@ -0,0 +1,180 @@
+package parse
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// === RUN   TestName
+	updateRe = regexp.MustCompile(`^=== (RUN|PAUSE|CONT|NAME)\s+(\S+)`)
+	// --- FAIL: TestName (0.01s), indented for subtests.
+	reportRe = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \((\d+(?:\.\d+)?)s\)`)
+	// ok  	example.com/pkg	0.01s, FAIL	example.com/pkg [build failed] or
+	// ?   	example.com/pkg	[no test files]
+	packageResultRe = regexp.MustCompile(`^(ok  |FAIL|\?   )\t(\S+)(?:\t(\d+(?:\.\d+)?)s)?`)
+)
+
+// IsText reports whether line, the first line of some input, is plain go test -v
+// output rather than go test -json events.
+func IsText(line string) bool {
+	line = strings.TrimRight(line, "\r\n")
+	return line == "PASS" || line == "FAIL" ||
+		updateRe.MatchString(line) ||
+		reportRe.MatchString(line) ||
+		packageResultRe.MatchString(line)
+}
+
+// jsonEvent is an Event as written by go test -json, which omits empty fields.
+type jsonEvent struct {
+	Time    time.Time `json:",omitempty"`
+	Action  Action
+	Package string  `json:",omitempty"`
+	Test    string  `json:",omitempty"`
+	Output  string  `json:",omitempty"`
+	Elapsed float64 `json:",omitempty"`
+}
+
+// ConvertText converts the plain text output of go test -v, or of a test binary run
+// with -test.v, read from r into go test -json events written to w, as go tool
+// test2json does. Events are written once their package is known from its result
+// line, such as "ok  \texample.com/pkg\t0.01s". Output without a result line, as
+// printed by a test binary, is attributed to pkg.
+func ConvertText(w io.Writer, r io.Reader, pkg string) error {
+	c := &converter{enc: json.NewEncoder(w), start: time.Now()}
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			if err := c.line(strings.TrimSuffix(line, "\n")); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return c.close(pkg)
+}
+
+type converter struct {
+	enc   *json.Encoder
+	start time.Time
+
+	// pending holds the events of the current package until its result line.
+	pending []jsonEvent
+	// test is the test that output is attributed to.
+	test string
+	// failed is set when the current package printed a failure.
+	failed bool
+}
+
+func (c *converter) emit(action Action, test, output string, elapsed float64) {
+	c.pending = append(c.pending, jsonEvent{
+		Time:    time.Now(),
+		Action:  action,
+		Test:    test,
+		Output:  output,
+		Elapsed: elapsed,
+	})
+}
+
+func (c *converter) line(s string) error {
+	output := s + "\n"
+
+	if m := updateRe.FindStringSubmatch(s); m != nil {
+		c.test = m[2]
+		switch m[1] {
+		case "RUN":
+			c.emit(ActionRun, c.test, "", 0)
+		case "PAUSE":
+			c.emit(ActionPause, c.test, "", 0)
+		case "CONT":
+			c.emit(ActionCont, c.test, "", 0)
+		}
+		c.emit(ActionOutput, c.test, output, 0)
+		return nil
+	}
+
+	if m := reportRe.FindStringSubmatch(s); m != nil {
+		// Output following a report belongs to that test, as printed before go1.14.
+		c.test = m[2]
+		elapsed, _ := strconv.ParseFloat(m[3], 64)
+		c.emit(ActionOutput, c.test, output, 0)
+		c.emit(Action(strings.ToLower(m[1])), c.test, "", elapsed)
+		if m[1] == "FAIL" {
+			c.failed = true
+		}
+		return nil
+	}
+
+	if m := packageResultRe.FindStringSubmatch(s); m != nil {
+		action := ActionPass
+		switch m[1] {
+		case "FAIL":
+			action = ActionFail
+		case "?   ":
+			action = ActionSkip
+		}
+		elapsed, _ := strconv.ParseFloat(m[3], 64)
+		c.test = ""
+		c.emit(ActionOutput, "", output, 0)
+		c.emit(action, "", "", elapsed)
+		return c.flush(m[2])
+	}
+
+	switch s {
+	case "PASS":
+		c.test = ""
+	case "FAIL":
+		c.test = ""
+		c.failed = true
+	}
+	if strings.HasPrefix(s, "panic: ") {
+		c.failed = true
+	}
+	c.emit(ActionOutput, c.test, output, 0)
+	return nil
+}
+
+// flush writes the pending events as those of package pkg.
+func (c *converter) flush(pkg string) error {
+	for _, e := range c.pending {
+		e.Package = pkg
+		if err := c.enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	c.pending = c.pending[:0]
+	c.failed = false
+	return nil
+}
+
+// close writes any remaining events as those of package pkg, followed by its result.
+// The final FAIL printed by go test -v for several packages is dropped.
+func (c *converter) close(pkg string) error {
+	var remaining bool
+	for _, e := range c.pending {
+		if e.Output != "PASS\n" && e.Output != "FAIL\n" {
+			remaining = true
+		}
+	}
+	if !remaining {
+		return nil
+	}
+	action := ActionPass
+	if c.failed {
+		action = ActionFail
+	}
+	c.emit(action, "", "", time.Since(c.start).Seconds())
+	return c.flush(pkg)
+}
@@ -0,0 +1,136 @@
+package parse
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConvertText(t *testing.T) {
+
+	t.Parallel()
+
+	// input01.txt is go test -v output for three packages: a failed one, one without
+	// test files and a passing one.
+	f, err := os.Open(filepath.Join("testdata", "convert", "input01.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	if err := ConvertText(&buf, f, "unused"); err != nil {
+		t.Fatal(err)
+	}
+	pkgs, err := Process(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pkgs) != 3 {
+		t.Fatalf("got %d packages, want 3", len(pkgs))
+	}
+
+	tt := []struct {
+		pkg              string
+		action           Action
+		elapsed          float64
+		pass, fail, skip int
+	}{
+		// 0
+		{"example.com/calc", ActionFail, 0.015, 2, 1, 1},
+		// 1
+		{"example.com/util", ActionPass, 0.205, 1, 0, 0},
+	}
+
+	for i, test := range tt {
+		pkg, ok := pkgs[test.pkg]
+		if !ok {
+			t.Fatalf("%d: missing package %s", i, test.pkg)
+		}
+		if pkg.Summary.Action != test.action {
+			t.Errorf("%d: got action %s, want %s", i, pkg.Summary.Action, test.action)
+		}
+		if pkg.Summary.Elapsed != test.elapsed {
+			t.Errorf("%d: got elapsed %v, want %v", i, pkg.Summary.Elapsed, test.elapsed)
+		}
+		pass := len(pkg.TestsByAction(ActionPass))
+		fail := len(pkg.TestsByAction(ActionFail))
+		skip := len(pkg.TestsByAction(ActionSkip))
+		if pass != test.pass || fail != test.fail || skip != test.skip {
+			t.Errorf("%d: got pass/fail/skip %d/%d/%d, want %d/%d/%d", i, pass, fail, skip, test.pass, test.fail, test.skip)
+		}
+	}
+
+	if pkg := pkgs["example.com/calc/cmd"]; pkg == nil || !pkg.NoTestFiles {
+		t.Error("example.com/calc/cmd: want no test files")
+	}
+
+	add := pkgs["example.com/calc"].GetTest("TestAdd")
+	if add == nil {
+		t.Fatal("missing TestAdd")
+	}
+	if got := add.Output(); !strings.Contains(got, "add_test.go:7: bad sum") {
+		t.Errorf("TestAdd output %q does not contain the failure", got)
+	}
+}
+
+func TestConvertTextBinary(t *testing.T) {
+
+	t.Parallel()
+
+	// A test binary run with -test.v prints no package result line.
+	input := "=== RUN   TestPanic\n--- FAIL: TestPanic (0.00s)\npanic: boom [recovered]\n"
+
+	var buf bytes.Buffer
+	if err := ConvertText(&buf, strings.NewReader(input), "example.com/crash"); err != nil {
+		t.Fatal(err)
+	}
+	pkgs, err := Process(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, ok := pkgs["example.com/crash"]
+	if !ok {
+		t.Fatalf("missing package, got %v", pkgs)
+	}
+	if pkg.Summary.Action != ActionFail {
+		t.Errorf("got action %s, want fail", pkg.Summary.Action)
+	}
+}
+
+func TestIsText(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		line string
+		want bool
+	}{
+		// 0
+		{"=== RUN   TestAdd\n", true},
+		// 1
+		{"--- FAIL: TestAdd (0.01s)\n", true},
+		// 2
+		{"ok  \texample.com/util\t0.205s\n", true},
+		// 3
+		{"?   \texample.com/calc/cmd\t[no test files]\n", true},
+		// 4
+		{"PASS\n", true},
+		// 5
+		{`{"Time":"2018-10-24T14:32:01.31052-04:00","Action":"run","Package":"example.com/util","Test":"TestOK"}` + "\n", false},
+		// 6: build errors precede go test -json output.
+		{"# example.com/calc\n", false},
+		// 7
+		{"", false},
+	}
+
+	for i, test := range tt {
+		if got := IsText(test.line); got != test.want {
+			t.Errorf("%d: IsText(%q) = %v, want %v", i, test.line, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package parse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CoverBlock is a block of statements in a coverage profile written by go test
+// -coverprofile.
+type CoverBlock struct {
+	StartLine, EndLine int
+	Statements         int
+	Count              int
+}
+
+// CoverProfile maps file names, as import path and base name ("example.com/pkg/file.go"),
+// to their coverage blocks.
+type CoverProfile map[string][]CoverBlock
+
+// ReadCoverProfile reads a coverage profile. Blocks listed more than once, as when
+// profiles of several packages cover the same file, have their counts summed.
+func ReadCoverProfile(r io.Reader) (CoverProfile, error) {
+	profile := make(CoverProfile)
+	index := make(map[string]int)
+
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "mode:") {
+			continue
+		}
+		// file.go:startLine.startCol,endLine.endCol statements count
+		i := strings.LastIndex(line, ":")
+		fields := strings.Fields(line[i+1:])
+		if i < 0 || len(fields) != 3 {
+			return nil, fmt.Errorf("coverage profile line %d: malformed block %q", n, line)
+		}
+		file := line[:i]
+		var b CoverBlock
+		var startCol, endCol int
+		if _, err := fmt.Sscanf(fields[0], "%d.%d,%d.%d", &b.StartLine, &startCol, &b.EndLine, &endCol); err != nil {
+			return nil, fmt.Errorf("coverage profile line %d: %v", n, err)
+		}
+		var err error
+		if b.Statements, err = strconv.Atoi(fields[1]); err != nil {
+			return nil, fmt.Errorf("coverage profile line %d: %v", n, err)
+		}
+		if b.Count, err = strconv.Atoi(fields[2]); err != nil {
+			return nil, fmt.Errorf("coverage profile line %d: %v", n, err)
+		}
+
+		key := file + ":" + fields[0]
+		if j, ok := index[key]; ok {
+			profile[file][j].Count += b.Count
+			continue
+		}
+		index[key] = len(profile[file])
+		profile[file] = append(profile[file], b)
+	}
+	return profile, sc.Err()
+}
+
+// LineRange is an inclusive range of source lines.
+type LineRange struct {
+	Start, End int
+}
+
+func (r LineRange) String() string {
+	if r.Start == r.End {
+		return strconv.Itoa(r.Start)
+	}
+	return strconv.Itoa(r.Start) + "-" + strconv.Itoa(r.End)
+}
+
+// Uncovered returns the line ranges of the file with statements that were never run,
+// merging overlapping and adjacent ranges.
+func (p CoverProfile) Uncovered(file string) []LineRange {
+	var ranges []LineRange
+	for _, b := range p[file] {
+		if b.Count == 0 && b.Statements > 0 {
+			ranges = append(ranges, LineRange{b.StartLine, b.EndLine})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+
+	var merged []LineRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r.Start <= merged[n-1].End+1 {
+			if r.End > merged[n-1].End {
+				merged[n-1].End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// FailureFiles returns the profile files of the package that are referenced by the
+// output of its failed tests, sorted. A reference to a test file, such as
+// "user_test.go:12", stands for the file it tests, "user.go".
+func (p CoverProfile) FailureFiles(pkg *Package) []string {
+	seen := make(map[string]bool)
+	for _, t := range pkg.TestsByAction(ActionFail) {
+		for _, loc := range Locations(t.Output()) {
+			base := path.Base(strings.Replace(loc.File, "\\", "/", -1))
+			base = strings.TrimSuffix(base, "_test.go")
+			if !strings.HasSuffix(base, ".go") {
+				base += ".go"
+			}
+			file := pkg.Summary.Package + "/" + base
+			if _, ok := p[file]; ok {
+				seen[file] = true
+			}
+		}
+	}
+
+	files := make([]string, 0, len(seen))
+	for file := range seen {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Percent returns the percentage of statements covered over all files, as reported
+// by go tool cover -func, or zero if the profile has no statements.
+func (p CoverProfile) Percent() float64 {
+	var total, covered int
+	for _, blocks := range p {
+		for _, b := range blocks {
+			total += b.Statements
+			if b.Count > 0 {
+				covered += b.Statements
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) / float64(total) * 100
+}
@@ -0,0 +1,112 @@
+package parse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCoverProfileUncovered(t *testing.T) {
+
+	t.Parallel()
+
+	f, err := os.Open(filepath.Join("testdata", "cover", "profile.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	profile, err := ReadCoverProfile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		file string
+		want []LineRange
+	}{
+		// 0: overlapping and adjacent blocks are merged.
+		{"example.com/user/user.go", []LineRange{{14, 18}, {22, 25}, {30, 30}}},
+		// 1: a block listed twice is covered if either is.
+		{"example.com/user/store.go", nil},
+		// 2
+		{"example.com/user/missing.go", nil},
+	}
+
+	for i, test := range tt {
+		if got := profile.Uncovered(test.file); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: got %v, want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestCoverProfilePercent(t *testing.T) {
+
+	t.Parallel()
+
+	f, err := os.Open(filepath.Join("testdata", "cover", "profile.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	profile, err := ReadCoverProfile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 4 of 11 statements are covered, counting the block listed twice once.
+	if got, want := profile.Percent(), 4.0/11*100; got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := (CoverProfile{}).Percent(); got != 0 {
+		t.Errorf("got %v for an empty profile, want 0", got)
+	}
+}
+
+func TestCoverProfileMalformed(t *testing.T) {
+
+	t.Parallel()
+
+	_, err := ReadCoverProfile(strings.NewReader("mode: set\nexample.com/user/user.go:10.30,12.2 1\n"))
+	if err == nil {
+		t.Fatal("want error for malformed block")
+	}
+}
+
+func TestCoverProfileFailureFiles(t *testing.T) {
+
+	t.Parallel()
+
+	f, err := os.Open(filepath.Join("testdata", "cover", "profile.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	profile, err := ReadCoverProfile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// input01.json has failures at user_test.go:42, standing for user.go, and at
+	// store.go:6. helpers_test.go has no helpers.go in the profile.
+	by, err := ioutil.ReadFile(filepath.Join("testdata", "cover", "input01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := profile.FailureFiles(pkgs["example.com/user"])
+	want := []string{"example.com/user/store.go", "example.com/user/user.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
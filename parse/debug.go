@@ -0,0 +1,84 @@
+package parse
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// WithDebugLog writes a log of the decisions taken while processing to w, such as
+// discarded events, unattributed output, unknown actions and package status changes,
+// to diagnose miscounted tests. Each decision is a logfmt line with the 1-based input
+// line number, e.g.
+//
+//	line=12 decision=discard reason="update line" package=example.com/a test=TestA action=output
+func WithDebugLog(w io.Writer) OptionsFunc {
+	return func(o *options) {
+		o.debug = &debugLog{w: w}
+	}
+}
+
+// debugLog writes parse decisions as logfmt lines. A nil debugLog discards them.
+type debugLog struct {
+	w io.Writer
+}
+
+// log records decision for the event e on line n, which may be nil, followed by the
+// given key and value pairs.
+func (l *debugLog) log(n int, decision string, e *Event, kv ...string) {
+	if l == nil {
+		return
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "line=%d decision=%s", n, decision)
+	for i := 0; i+1 < len(kv); i += 2 {
+		writeLogfmt(&sb, kv[i], kv[i+1])
+	}
+	if e != nil {
+		writeLogfmt(&sb, "package", e.Package)
+		writeLogfmt(&sb, "test", e.Test)
+		writeLogfmt(&sb, "action", string(e.Action))
+		writeLogfmt(&sb, "output", strings.TrimRight(e.Output, "\n"))
+	}
+	sb.WriteByte('\n')
+	io.WriteString(l.w, sb.String())
+}
+
+// writeLogfmt writes the pair key=value, quoting value if needed. Empty values are
+// left out.
+func writeLogfmt(sb *strings.Builder, key, value string) {
+	if value == "" {
+		return
+	}
+	if strings.IndexFunc(value, func(r rune) bool {
+		return r <= ' ' || r == '"' || r == '=' || r == '\\' || !unicode.IsPrint(r)
+	}) >= 0 {
+		value = strconv.Quote(value)
+	}
+	sb.WriteString(" " + key + "=" + value)
+}
+
+// knownAction reports whether a is an action written by go test -json.
+func knownAction(a Action) bool {
+	switch a {
+	case ActionStart, ActionRun, ActionPause, ActionCont, ActionPass, ActionBench,
+		ActionFail, ActionOutput, ActionSkip:
+		return true
+	}
+	return false
+}
+
+// discardReason returns why the discarded event e is left out of its package.
+func discardReason(e *Event) string {
+	if e.Action == ActionStart {
+		return "start action"
+	}
+	for i := range updates {
+		if strings.HasPrefix(e.Output, updates[i]) {
+			return "update line"
+		}
+	}
+	return "package output"
+}
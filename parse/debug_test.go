@@ -0,0 +1,51 @@
+package parse
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDebugLog(t *testing.T) {
+
+	t.Parallel()
+
+	input := `{"Action":"start","Package":"example.com/a"}
+not json
+{"Action":"run","Package":"example.com/a","Test":"TestA"}
+{"Action":"output","Package":"example.com/a","Test":"TestA","Output":"=== RUN   TestA\n"}
+{"Action":"output","Package":"example.com/a","Output":"init log\n"}
+{"Action":"frobnicate","Package":"example.com/a","Test":"TestA"}
+{"Action":"pass","Package":"example.com/a","Test":"TestA"}
+{"Action":"output","Package":"example.com/a","Output":"coverage: 50.0% of statements\n"}
+{"Action":"pass","Package":"example.com/a"}
+`
+
+	var buf bytes.Buffer
+	if _, err := Process(strings.NewReader(input), WithDebugLog(&buf)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		// 0
+		`line=1 decision=discard reason="start action" package=example.com/a action=start`,
+		// 1
+		`line=2 decision=bad-line error=`,
+		// 2
+		`line=4 decision=discard reason="update line" package=example.com/a test=TestA action=output output="=== RUN   TestA"`,
+		// 3: init output is attributed to the running test.
+		`line=5 decision=attribute package=example.com/a test=TestA action=output output="init log"`,
+		// 4
+		`line=6 decision=unknown-action package=example.com/a test=TestA action=frobnicate`,
+		// 5
+		`line=8 decision=coverage coverage=50 package=example.com/a action=output`,
+		// 6
+		`line=9 decision=summary package=example.com/a action=pass`,
+	}
+	got := buf.String()
+	for i, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("%d: got log\n%s\nwant it to contain %q", i, got, w)
+		}
+	}
+}
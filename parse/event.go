@@ -14,7 +14,7 @@ import (
 // https://github.com/golang/go/blob/master/src/cmd/internal/test2json/test2json.go
 type Event struct {
 	// Action can be one of:
-	// run, pause, cont, pass, bench, fail, output, skip
+	// start, run, pause, cont, pass, bench, fail, output, skip
 	Action Action
 
 	// Portion of the test's output (standard output and standard error merged together)
@@ -50,6 +50,9 @@ func NewEvent(data []byte) (*Event, error) {
 
 // ProcessNestedTest checks to see if the event is actually really a nested
 // test
+//
+// Deprecated: Process parses the output of gocheck suites in full, including the
+// output and duration of each check.
 func (e *Event) ProcessNestedTest() {
 	if e.NestedTest() {
 		if strings.HasPrefix(e.Output, "PASS") {
@@ -73,8 +76,14 @@ type Events []*Event
 //
 // 2. has no test name
 //
+// 3. is a start action, which marks the beginning of a package test binary (go1.20+)
+//
 // If output is not one of the above return false.
 func (e *Event) Discard() bool {
+	if e.Action == ActionStart {
+		return true
+	}
+
 	for i := range updates {
 		if strings.HasPrefix(e.Output, updates[i]) {
 			return true
@@ -84,11 +93,27 @@ func (e *Event) Discard() bool {
 	return e.Action == ActionOutput && e.Test == ""
 }
 
+// Unattributed reports whether the event is output that does not belong to any test,
+// and is neither an update line nor a package framing line. For example, log
+// output from init() or a background goroutine.
+func (e *Event) Unattributed() bool {
+	if e.Action != ActionOutput || e.Test != "" || e.PackageFraming() {
+		return false
+	}
+	for i := range updates {
+		if strings.HasPrefix(e.Output, updates[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 var (
 	updates = []string{
 		"=== RUN   ",
 		"=== PAUSE ",
 		"=== CONT  ",
+		"=== NAME",
 	}
 )
 
@@ -165,11 +190,49 @@ func (e *Event) Cover() (float64, bool) {
 	return f, false
 }
 
+// PackageFraming reports whether the event is an output line emitted by go test
+// for the package as a whole, rather than by a test. For example:
+//
+// "PASS\n"
+// "FAIL\tgithub.com/mfridman/tparse/tests\t0.012s\n"
+// "ok  \tgithub.com/mfridman/srfax\t0.027s\tcoverage: 28.8% of statements\n"
+func (e *Event) PackageFraming() bool {
+	if e.Test != "" || e.Action != ActionOutput {
+		return false
+	}
+	if _, ok := e.Cover(); ok {
+		return true
+	}
+	for i := range framing {
+		if strings.HasPrefix(e.Output, framing[i]) {
+			return true
+		}
+	}
+	return e.Output == "PASS\n" || e.Output == "FAIL\n" || e.NoTestFiles() || e.NoTestsToRun()
+}
+
+var (
+	framing = []string{
+		"ok  \t",
+		"FAIL\t",
+		"?   \t",
+		"exit status ",
+		"testing: warning: no tests to run",
+	}
+)
+
 // IsRace indicates a race event has been detected.
 func (e *Event) IsRace() bool {
 	return strings.HasPrefix(e.Output, "WARNING: DATA RACE")
 }
 
+// BuildFailed reports whether the event is the result line of a package that failed
+// to build: "FAIL\tpackage [build failed]\n" or "FAIL\tpackage [setup failed]\n".
+func (e *Event) BuildFailed() bool {
+	return e.Test == "" && strings.HasPrefix(e.Output, "FAIL\t") &&
+		(strings.HasSuffix(e.Output, "[build failed]\n") || strings.HasSuffix(e.Output, "[setup failed]\n"))
+}
+
 // IsPanic indicates a panic event has been detected.
 func (e *Event) IsPanic() bool {
 	// Let's see how this goes. If a user has this in one of their output lines, I think it's
@@ -186,6 +249,7 @@ type Action string
 
 // Prefixed with Action for convenience.
 const (
+	ActionStart  Action = "start"  // the test binary is about to be executed (go1.20+)
 	ActionRun    Action = "run"    // test has started running
 	ActionPause  Action = "pause"  // test has been paused
 	ActionCont   Action = "cont"   // the test has continued running
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"strings"
 	"testing"
 
@@ -87,6 +88,11 @@ func TestNewEvent(t *testing.T) {
 			true,
 			false,
 		},
+		{
+			// 10
+			`{"Time":"2023-05-24T08:48:23.634909-04:00","Action":"start","Package":"github.com/mfridman/srfax"}`,
+			ActionStart, "github.com/mfridman/srfax", "", "", true, false,
+		},
 	}
 
 	for i, test := range tt {
@@ -508,4 +514,106 @@ func TestPackageCover(t *testing.T) {
 			}
 		})
 	}
+
+	if got, ok := pkgs.Coverage(); !ok || math.Abs(got-(68.0+86.7+60.8)/3) > 1e-9 {
+		t.Fatalf("got mean coverage %v (%v), want the mean of all packages", got, ok)
+	}
+}
+
+func TestPackageFraming(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		input   string
+		framing bool
+	}{
+		{
+			// 0
+			`{"Time":"2018-10-17T22:27:03.034118-04:00","Action":"output","Package":"github.com/mfridman/tparse/tests","Output":"FAIL\n"}`, true,
+		},
+		{
+			// 1
+			`{"Time":"2018-10-17T22:27:03.03447-04:00","Action":"output","Package":"github.com/mfridman/tparse/tests","Output":"FAIL\tgithub.com/mfridman/tparse/tests\t0.012s\n"}`, true,
+		},
+		{
+			// 2
+			`{"Time":"2018-10-24T09:25:59.855826-04:00","Action":"output","Package":"github.com/mfridman/srfax","Output":"ok  \tgithub.com/mfridman/srfax\t0.027s\tcoverage: 87.5% of statements\n"}`, true,
+		},
+		{
+			// 3
+			`{"Time":"2018-10-24T09:25:59.855826-04:00","Action":"output","Package":"github.com/mfridman/srfax","Output":"coverage: 87.5% of statements\n"}`, true,
+		},
+		{
+			// 4
+			`{"Time":"2018-10-15T23:00:27.929094-04:00","Action":"output","Package":"github.com/astromail/rover/tests","Output":"2018/10/15 23:00:27 Replaying from value pointer: {Fid:0 Len:0 Offset:0}\n"}`, false,
+		},
+		{
+			// 5
+			`{"Time":"2018-10-17T22:27:03.034118-04:00","Action":"output","Package":"github.com/mfridman/tparse/tests","Test":"TestStatus","Output":"FAIL\n"}`, false,
+		},
+	}
+
+	for i, test := range tt {
+
+		t.Run(fmt.Sprintf("event_%d", i), func(t *testing.T) {
+			e, err := NewEvent([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := e.PackageFraming(); got != test.framing {
+				t.Errorf("got (%t), want (%t) for package framing", got, test.framing)
+				t.Logf("input: %v", test.input)
+			}
+		})
+
+	}
+}
+
+func TestBuildFailed(t *testing.T) {
+	// FAIL	package [build failed]
+
+	t.Parallel()
+
+	tt := []struct {
+		input       string
+		buildFailed bool
+	}{
+		{
+			// 0
+			`{"Time": "2018-10-28T00:06:53.478265-04:00", "Action": "output", "Package": "github.com/astromail/rover", "Output": "FAIL\tgithub.com/astromail/rover [build failed]\n"}`, true,
+		},
+		{
+			// 1
+			`{"Time": "2018-10-28T00:06:53.478265-04:00", "Action": "output", "Package": "github.com/astromail/rover", "Output": "FAIL\tgithub.com/astromail/rover [setup failed]\n"}`, true,
+		},
+		{
+			// 2
+			`{"Time": "2018-10-28T00:06:53.478265-04:00", "Action": "output", "Package": "github.com/astromail/rover", "Output": "FAIL\tgithub.com/astromail/rover\t0.012s\n"}`, false,
+		},
+		{
+			// 3
+			`{"Time": "2018-10-28T00:06:53.478265-04:00", "Action": "output", "Package": "github.com/astromail/rover", "Test": "TestBuild", "Output": "FAIL\tgithub.com/astromail/rover [build failed]\n"}`, false,
+		},
+	}
+
+	for i, test := range tt {
+
+		t.Run(fmt.Sprintf("event_%d", i), func(t *testing.T) {
+			e, err := NewEvent([]byte(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := e.BuildFailed()
+			want := test.buildFailed
+
+			if got != want {
+				t.Errorf("got (%t), want (%t) for build failed", got, want)
+				t.Logf("input: %v", test.input)
+			}
+		})
+
+	}
 }
@@ -0,0 +1,282 @@
+package parse
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// GinkgoSuite holds the spec counts of a Ginkgo suite, as printed at its end:
+//
+//	Ran 3 of 5 Specs in 0.012 seconds
+//	FAIL! -- 2 Passed | 1 Failed | 1 Pending | 1 Skipped
+type GinkgoSuite struct {
+	Specs, Ran                       int
+	Passed, Failed, Pending, Skipped int
+
+	// Focused is set when specs were focused programmatically, with FIt, FDescribe
+	// and friends, so only the focused specs ran.
+	Focused bool
+
+	// Test is the go test test that ran the suite with RunSpecs, if any.
+	Test string
+}
+
+var (
+	ansiRe         = regexp.MustCompile("\x1b\\[[0-9;]*m")
+	ginkgoRanRe    = regexp.MustCompile(`^Ran (\d+) of (\d+) Specs? in `)
+	ginkgoCountsRe = regexp.MustCompile(`^(?:SUCCESS|FAIL)!(?: - .*)? -- (\d+) Passed \| (\d+) Failed \| (?:\d+ Flaked \| )?(\d+) Pending \| (\d+) Skipped`)
+)
+
+// ginkgoSuite updates the Ginkgo suite of the package from e, an output line printed
+// at the end of a Ginkgo suite, and reports whether it was one.
+func (p *Package) ginkgoSuite(e *Event) bool {
+	if e.Action != ActionOutput {
+		return false
+	}
+	// Most lines are not, so rule them out before the regular expressions.
+	if !strings.Contains(e.Output, "Ran ") && !strings.Contains(e.Output, " Passed") &&
+		!strings.Contains(e.Output, "Programmatic Focus") {
+		return false
+	}
+	line := ansiRe.ReplaceAllString(strings.TrimSpace(e.Output), "")
+	suite := func() *GinkgoSuite {
+		if p.Ginkgo == nil {
+			p.Ginkgo = &GinkgoSuite{}
+		}
+		if e.Test != "" {
+			p.Ginkgo.Test = e.Test
+		}
+		return p.Ginkgo
+	}
+
+	if m := ginkgoRanRe.FindStringSubmatch(line); m != nil {
+		s := suite()
+		s.Ran, _ = strconv.Atoi(m[1])
+		s.Specs, _ = strconv.Atoi(m[2])
+		return true
+	}
+	counts := ginkgoCountsRe.FindStringSubmatch(line)
+	if counts != nil {
+		s := suite()
+		s.Passed, _ = strconv.Atoi(counts[1])
+		s.Failed, _ = strconv.Atoi(counts[2])
+		s.Pending, _ = strconv.Atoi(counts[3])
+		s.Skipped, _ = strconv.Atoi(counts[4])
+	}
+	// Ginkgo v2 reports focus on the counts line, v1 on a line of its own.
+	focused := strings.Contains(line, "Detected Programmatic Focus")
+	if focused {
+		suite().Focused = true
+	}
+	return counts != nil || focused
+}
+
+// IsGinkgoReport reports whether head, the first lines of some input, starts a Ginkgo
+// JSON report, as written by ginkgo --json-report, rather than go test -json events or
+// other output starting with a bracket, such as "[INFO]" log lines. Ginkgo writes the
+// SuitePath of the first suite first, so head must hold it.
+func IsGinkgoReport(head string) bool {
+	head = strings.TrimLeft(head, "\ufeff \t\r\n")
+	if !strings.HasPrefix(head, "[") {
+		return false
+	}
+	head = strings.TrimLeft(head[1:], " \t\r\n")
+	return strings.HasPrefix(head, "{") && strings.Contains(head, `"SuitePath"`)
+}
+
+// MergeGinkgo merges the packages parsed from Ginkgo reports into those of the go test
+// output of the same run. The test that ran a suite with RunSpecs, such as TestBooks,
+// is dropped from a package with specs in the reports, which take its place.
+//
+// The packages are reused and modified.
+func MergeGinkgo(reports, pkgs Packages) Packages {
+	for name, pkg := range pkgs {
+		report, ok := reports[name]
+		if !ok || len(report.Tests) == 0 || pkg.Ginkgo == nil || pkg.Ginkgo.Test == "" {
+			continue
+		}
+		bootstrap := pkg.Ginkgo.Test
+		tests := pkg.Tests
+		pkg.Tests, pkg.index = nil, nil
+		for _, t := range tests {
+			if t.Name != bootstrap && !strings.HasPrefix(t.Name, bootstrap+"/") {
+				pkg.addTest(t)
+			}
+		}
+	}
+	// The suite counts of the reports are kept over those printed to the output.
+	return MergeShards([]Packages{reports, pkgs})
+}
+
+// ginkgoReport is a suite of a Ginkgo JSON report.
+type ginkgoReport struct {
+	SuitePath                 string
+	SuiteDescription          string
+	SuiteSucceeded            bool
+	SuiteHasProgrammaticFocus bool
+	PreRunStats               struct {
+		TotalSpecs int
+	}
+	StartTime   time.Time
+	RunTime     time.Duration
+	SpecReports []ginkgoSpec
+}
+
+type ginkgoSpec struct {
+	ContainerHierarchyTexts    []string
+	LeafNodeType               string
+	LeafNodeText               string
+	State                      string
+	StartTime                  time.Time
+	EndTime                    time.Time
+	RunTime                    time.Duration
+	Failure                    *ginkgoFailure
+	CapturedGinkgoWriterOutput string
+	CapturedStdOutErr          string
+}
+
+type ginkgoFailure struct {
+	Message        string
+	ForwardedPanic string
+	Location       struct {
+		FileName   string
+		LineNumber int
+	}
+}
+
+// ConvertGinkgo converts the Ginkgo JSON report read from r into go test -json events
+// written to w, so it can be processed as the output of go test. Each suite becomes
+// a package named by pkg from the directory of the suite, and each spec a test named
+// by its containers and text, as Ginkgo prints it, e.g. "Books Categorizing should
+// be a novel". Pending specs are skipped with the reason "pending". The spec counts
+// of each suite are printed as Ginkgo does, to set the Ginkgo suite of the package.
+func ConvertGinkgo(w io.Writer, r io.Reader, pkg func(dir string) string) error {
+	var reports []ginkgoReport
+	if err := json.NewDecoder(r).Decode(&reports); err != nil {
+		return errors.Wrap(err, "failed to decode Ginkgo JSON report")
+	}
+
+	enc := json.NewEncoder(w)
+	for _, report := range reports {
+		if err := convertGinkgoSuite(enc, report, pkg(report.SuitePath)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func convertGinkgoSuite(enc *json.Encoder, report ginkgoReport, pkg string) error {
+	var events []jsonEvent
+	emit := func(at time.Time, action Action, test, output string, elapsed float64) {
+		events = append(events, jsonEvent{
+			Time:    at,
+			Action:  action,
+			Package: pkg,
+			Test:    test,
+			Output:  output,
+			Elapsed: elapsed,
+		})
+	}
+	outputLines := func(at time.Time, test, prefix, s string) {
+		s = strings.Trim(s, "\n")
+		if strings.TrimSpace(s) == "" {
+			return
+		}
+		for _, line := range strings.Split(s, "\n") {
+			emit(at, ActionOutput, test, prefix+line+"\n", 0)
+		}
+	}
+
+	var suite GinkgoSuite
+	suite.Specs = report.PreRunStats.TotalSpecs
+	suite.Focused = report.SuiteHasProgrammaticFocus
+	for _, spec := range report.SpecReports {
+		name := strings.TrimSpace(strings.Join(append(append([]string(nil), spec.ContainerHierarchyTexts...), spec.LeafNodeText), " "))
+		if spec.LeafNodeType != "" && spec.LeafNodeType != "It" {
+			name = strings.TrimSpace("[" + spec.LeafNodeType + "] " + name)
+		}
+		elapsed := spec.RunTime.Seconds()
+
+		action := ActionFail
+		switch spec.State {
+		case "passed":
+			action = ActionPass
+		case "skipped", "pending":
+			action = ActionSkip
+		}
+
+		// Suite nodes, such as a failed BeforeSuite, are reported but are not specs.
+		if spec.LeafNodeType == "It" {
+			switch spec.State {
+			case "passed":
+				suite.Passed++
+			case "skipped":
+				suite.Skipped++
+			case "pending":
+				suite.Pending++
+			default: // failed, panicked, interrupted, aborted, timedout
+				suite.Failed++
+			}
+			if action != ActionSkip {
+				suite.Ran++
+			}
+		}
+
+		emit(spec.StartTime, ActionRun, name, "", 0)
+		emit(spec.StartTime, ActionOutput, name, "=== RUN   "+name+"\n", 0)
+		outputLines(spec.StartTime, name, "", spec.CapturedGinkgoWriterOutput)
+		outputLines(spec.StartTime, name, "", spec.CapturedStdOutErr)
+		switch {
+		case spec.State == "pending":
+			outputLines(spec.EndTime, name, "    ", "pending")
+		case spec.Failure != nil && spec.Failure.Message != "":
+			msg := spec.Failure.Message
+			if spec.Failure.ForwardedPanic != "" {
+				msg += "\n" + spec.Failure.ForwardedPanic
+			}
+			if loc := spec.Failure.Location; loc.FileName != "" {
+				msg = fmt.Sprintf("%s:%d: %s", filepath.Base(loc.FileName), loc.LineNumber, msg)
+			}
+			outputLines(spec.EndTime, name, "    ", msg)
+		}
+		emit(spec.EndTime, ActionOutput, name, fmt.Sprintf("--- %s: %s (%.2fs)\n", strings.ToUpper(string(action)), name, elapsed), 0)
+		emit(spec.EndTime, action, name, "", elapsed)
+	}
+
+	end := report.StartTime.Add(report.RunTime)
+	elapsed := report.RunTime.Seconds()
+	result := "SUCCESS!"
+	if !report.SuiteSucceeded {
+		result = "FAIL!"
+	}
+	emit(end, ActionOutput, "", fmt.Sprintf("Ran %d of %d Specs in %.3f seconds\n", suite.Ran, suite.Specs, elapsed), 0)
+	emit(end, ActionOutput, "", fmt.Sprintf("%s -- %d Passed | %d Failed | %d Pending | %d Skipped\n",
+		result, suite.Passed, suite.Failed, suite.Pending, suite.Skipped), 0)
+	if suite.Focused {
+		emit(end, ActionOutput, "", "Detected Programmatic Focus - setting exit status to 197\n", 0)
+	}
+	if report.SuiteSucceeded {
+		emit(end, ActionOutput, "", "PASS\n", 0)
+		emit(end, ActionOutput, "", fmt.Sprintf("ok  \t%s\t%.3fs\n", pkg, elapsed), 0)
+		emit(end, ActionPass, "", "", elapsed)
+	} else {
+		emit(end, ActionOutput, "", "FAIL\n", 0)
+		emit(end, ActionOutput, "", fmt.Sprintf("FAIL\t%s\t%.3fs\n", pkg, elapsed), 0)
+		emit(end, ActionFail, "", "", elapsed)
+	}
+
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
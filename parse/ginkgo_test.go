@@ -0,0 +1,241 @@
+package parse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGinkgoSuite(t *testing.T) {
+
+	t.Parallel()
+
+	// input01.json contains a Ginkgo v2 suite run by TestBooks, which ran 1 of its 4
+	// specs because of programmatic focus.
+	f, err := os.Open(filepath.Join("testdata", "ginkgo", "input01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	pkgs, err := Process(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := pkgs["example.com/books"]
+	if pkg == nil {
+		t.Fatal("missing package")
+	}
+	want := GinkgoSuite{Specs: 4, Ran: 1, Passed: 1, Pending: 1, Skipped: 2, Focused: true, Test: "TestBooks"}
+	if pkg.Ginkgo == nil || *pkg.Ginkgo != want {
+		t.Errorf("got suite %+v, want %+v", pkg.Ginkgo, want)
+	}
+}
+
+func TestGinkgoSuiteLines(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		lines []string
+		want  *GinkgoSuite
+	}{
+		// 0
+		{[]string{"PASS\n"}, nil},
+		// 1 Ginkgo v1
+		{
+			[]string{
+				"Ran 3 of 5 Specs in 0.012 seconds\n",
+				"SUCCESS! -- 3 Passed | 0 Failed | 1 Pending | 1 Skipped\n",
+			},
+			&GinkgoSuite{Specs: 5, Ran: 3, Passed: 3, Pending: 1, Skipped: 1, Test: "TestSuite"},
+		},
+		// 2 Ginkgo v1 focus and flakes
+		{
+			[]string{
+				"Ran 2 of 3 Specs in 0.100 seconds\n",
+				"FAIL! -- 1 Passed | 1 Failed | 1 Flaked | 0 Pending | 1 Skipped\n",
+				"Detected Programmatic Focus - setting exit status to 197\n",
+			},
+			&GinkgoSuite{Specs: 3, Ran: 2, Passed: 1, Failed: 1, Skipped: 1, Focused: true, Test: "TestSuite"},
+		},
+		// 3 Ginkgo v2, interrupted
+		{
+			[]string{"FAIL! - Interrupted by User -- 0 Passed | 0 Failed | 0 Pending | 2 Skipped\n"},
+			&GinkgoSuite{Skipped: 2, Test: "TestSuite"},
+		},
+	}
+
+	for i, test := range tt {
+		p := NewPackage()
+		for _, line := range test.lines {
+			p.ginkgoSuite(&Event{Action: ActionOutput, Test: "TestSuite", Output: line})
+		}
+		switch {
+		case test.want == nil && p.Ginkgo != nil:
+			t.Errorf("%d: got suite %+v, want none", i, p.Ginkgo)
+		case test.want != nil && (p.Ginkgo == nil || *p.Ginkgo != *test.want):
+			t.Errorf("%d: got suite %+v, want %+v", i, p.Ginkgo, test.want)
+		}
+	}
+}
+
+func TestConvertGinkgo(t *testing.T) {
+
+	t.Parallel()
+
+	// report01.json is a report of ginkgo --json-report with a passed, a failed, a
+	// pending and a skipped spec, and an AfterSuite node.
+	f, err := os.Open(filepath.Join("testdata", "ginkgo", "report01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	err = ConvertGinkgo(&buf, f, func(dir string) string {
+		return "example.com/" + filepath.Base(dir)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkgs, err := Process(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := pkgs["example.com/books"]
+	if pkg == nil {
+		t.Fatal("missing package example.com/books")
+	}
+	if pkg.Summary.Action != ActionFail {
+		t.Errorf("got action %s, want fail", pkg.Summary.Action)
+	}
+	want := GinkgoSuite{Specs: 4, Ran: 2, Passed: 1, Failed: 1, Pending: 1, Skipped: 1}
+	if pkg.Ginkgo == nil || *pkg.Ginkgo != want {
+		t.Errorf("got suite %+v, want %+v", pkg.Ginkgo, want)
+	}
+
+	tt := []struct {
+		name   string
+		status Action
+		output string
+	}{
+		// 0
+		{"Books Categorizing should be a novel", ActionPass, "checking length"},
+		// 1
+		{"Books Categorizing should be a short story", ActionFail, "books_test.go:27: Expected"},
+		// 2
+		{"Books Lending should track the borrower", ActionSkip, "    pending"},
+		// 3
+		{"Books should load from disk", ActionSkip, ""},
+		// 4
+		{"[AfterSuite]", ActionPass, ""},
+	}
+
+	for i, test := range tt {
+		tc := pkg.GetTest(test.name)
+		if tc == nil {
+			t.Errorf("%d: missing test %q", i, test.name)
+			continue
+		}
+		if tc.Status() != test.status {
+			t.Errorf("%d: got status %s, want %s", i, tc.Status(), test.status)
+		}
+		if !strings.Contains(tc.Output(), test.output) {
+			t.Errorf("%d: output %q does not contain %q", i, tc.Output(), test.output)
+		}
+	}
+	if got := len(pkg.Tests); got != len(tt) {
+		t.Errorf("got %d tests, want %d", got, len(tt))
+	}
+}
+
+func TestConvertGinkgoInvalid(t *testing.T) {
+
+	t.Parallel()
+
+	pkg := func(dir string) string { return dir }
+	if err := ConvertGinkgo(new(bytes.Buffer), strings.NewReader("[{"), pkg); err == nil {
+		t.Error("want error for truncated report")
+	}
+}
+
+func TestIsGinkgoReport(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		head string
+		want bool
+	}{
+		// 0
+		{"[\n  {\n    \"SuitePath\": \"/src/books\",\n", true},
+		// 1
+		{`[{"SuitePath":"/src/books","SuiteDescription":"Books Suite"}]`, true},
+		// 2
+		{"[INFO] starting server\n", false},
+		// 3
+		{"[\n  {\n    \"name\": \"x\"\n", false},
+		// 4
+		{`{"Action":"run","Package":"p"}`, false},
+	}
+
+	for i, test := range tt {
+		if got := IsGinkgoReport(test.head); got != test.want {
+			t.Errorf("%d: got %v, want %v", i, got, test.want)
+		}
+	}
+}
+
+func TestMergeGinkgo(t *testing.T) {
+
+	t.Parallel()
+
+	f, err := os.Open(filepath.Join("testdata", "ginkgo", "report01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	err = ConvertGinkgo(&buf, f, func(dir string) string {
+		return "example.com/" + filepath.Base(dir)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports, err := Process(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	by, err := ioutil.ReadFile(filepath.Join("testdata", "ginkgo", "input01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An unrelated test of the same package is kept.
+	by = append(by, []byte(`{"Action":"run","Package":"example.com/books","Test":"TestOther"}`+"\n"+
+		`{"Action":"pass","Package":"example.com/books","Test":"TestOther"}`+"\n")...)
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := MergeGinkgo(reports, pkgs)["example.com/books"]
+	if pkg.GetTest("TestBooks") != nil {
+		t.Error("got the RunSpecs test TestBooks alongside the specs")
+	}
+	if pkg.GetTest("TestOther") == nil {
+		t.Error("missing test TestOther")
+	}
+	if pkg.GetTest("Books Categorizing should be a novel") == nil {
+		t.Error("missing spec")
+	}
+	if pkg.Summary.Action != ActionFail {
+		t.Errorf("got action %s, want fail", pkg.Summary.Action)
+	}
+}
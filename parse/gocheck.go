@@ -0,0 +1,97 @@
+package parse
+
+import (
+	"regexp"
+	"strconv"
+)
+
+var (
+	// The result line of a check, printed by gocheck (gopkg.in/check.v1) for failures
+	// and, with -check.v, for every check, or its start line with -check.vv:
+	//
+	// PASS: foo_test.go:20: FooSuite.TestOk	0.001s
+	// SKIP: foo_test.go:30: FooSuite.TestLater (not implemented)
+	// FAIL: foo_test.go:40: FooSuite.SetUpTest
+	checkLineRe = regexp.MustCompile(`^(START|PASS|FAIL|SKIP|PANIC|MISS|FAIL EXPECTED): (\S+\.go:\d+): (\S+)(?: \((.*)\))?(?:\t(\d+(?:\.\d+)?)s)?\n?$`)
+	// OOPS: 3 passed, 1 FAILED, 2 MISSED or OK: 4 passed
+	checkSummaryRe = regexp.MustCompile(`^(?:OK|OOPS): \d+ `)
+)
+
+// checkSeparator precedes the header of a failed or panicked check.
+const checkSeparator = "----------------------------------------------------------------------\n"
+
+// checker converts the output of gocheck suites, run by a Go test function such as
+// TestPackage, into events of each check, so that checks are reported as tests of
+// their own, named Suite.TestName. Failures of suite fixtures, such as
+// Suite.SetUpSuite, are reported as failed checks, and the checks missed because of
+// them as skipped.
+type checker struct {
+	// current is the check that output belongs to: from its START line or its FAIL or
+	// PANIC header until its result line, the next separator or the suite summary.
+	current string
+
+	// panicked is set while reading the log of a panicked check, which gocheck
+	// recovered from, so it does not fail the package as a panic.
+	panicked bool
+}
+
+// events returns the events for e: e itself, filed under the check its output belongs
+// to if any, and for a result line the actions of the check it reports.
+func (c *checker) events(e *Event) []*Event {
+	if e.Test == "" {
+		return []*Event{e}
+	}
+	if e.Action != ActionOutput {
+		// An action of the Go test function running the suites, such as its result.
+		c.current, c.panicked = "", false
+		return []*Event{e}
+	}
+
+	if e.Output == checkSeparator || checkSummaryRe.MatchString(e.Output) {
+		c.current, c.panicked = "", false
+		return []*Event{e}
+	}
+
+	m := checkLineRe.FindStringSubmatch(e.Output)
+	if m == nil {
+		if c.current != "" {
+			e.Test = c.current
+		}
+		return []*Event{e}
+	}
+
+	name := m[3]
+	elapsed, _ := strconv.ParseFloat(m[5], 64)
+	event := func(action Action, output string) *Event {
+		return &Event{
+			Time:    e.Time,
+			Action:  action,
+			Package: e.Package,
+			Test:    name,
+			Output:  output,
+			Elapsed: elapsed,
+		}
+	}
+
+	c.current, c.panicked = "", false
+	switch m[1] {
+	case "START":
+		c.current = name
+		return []*Event{e, event(ActionRun, "")}
+	case "PASS", "FAIL EXPECTED":
+		return []*Event{e, event(ActionPass, "")}
+	case "SKIP":
+		events := []*Event{e}
+		if m[4] != "" {
+			events = append(events, event(ActionOutput, "    "+m[4]+"\n"))
+		}
+		return append(events, event(ActionSkip, ""))
+	case "MISS":
+		return []*Event{e, event(ActionOutput, "    missed because a fixture of the suite failed\n"), event(ActionSkip, "")}
+	default: // FAIL, PANIC
+		// The log of the check follows its header.
+		c.current = name
+		c.panicked = m[1] == "PANIC"
+		return []*Event{e, event(ActionFail, "")}
+	}
+}
@@ -0,0 +1,94 @@
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGocheck(t *testing.T) {
+
+	t.Parallel()
+
+	// input01.json contains gocheck suites run by TestPackage with -check.vv: a passed,
+	// a skipped, a failed and a panicked check, a failed SetUpSuite fixture and a
+	// check missed because of it.
+	f, err := os.Open(filepath.Join("testdata", "gocheck", "input01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	pkgs, err := Process(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := pkgs["github.com/juju/juju/cmd/juju/machine"]
+	if pkg == nil {
+		t.Fatal("missing package")
+	}
+	if pkg.HasPanic {
+		t.Error("got package panic, want panic recovered by gocheck")
+	}
+
+	tt := []struct {
+		name     string
+		status   Action
+		elapsed  float64
+		location string
+		message  string
+	}{
+		// 0
+		{"FooSuite.TestOk", ActionPass, 0.012, "", ""},
+		// 1
+		{"FooSuite.TestLater", ActionSkip, 0, "", "not implemented"},
+		// 2
+		{"FooSuite.TestBroken", ActionFail, 0, "foo_test.go:42", "c.Assert(n, gc.Equals, 2)"},
+		// 3
+		{"FooSuite.TestIndex", ActionFail, 0, "foo_test.go:52", "... Panic: runtime error: index out of range [3] with length 3 (PC=0x4a5b6c)"},
+		// 4
+		{"BarSuite.SetUpSuite", ActionFail, 0, "bar_test.go:17", "c.Assert(err, jc.ErrorIsNil)"},
+		// 5
+		{"BarSuite.TestA", ActionSkip, 0, "", "missed because a fixture of the suite failed"},
+		// 6
+		{"TestPackage", ActionFail, 0.21, "", ""},
+	}
+
+	for i, test := range tt {
+		tc := pkg.GetTest(test.name)
+		if tc == nil {
+			t.Fatalf("%d: missing test %s", i, test.name)
+		}
+		if got := tc.Status(); got != test.status {
+			t.Errorf("%d: %s: got status %s, want %s", i, test.name, got, test.status)
+		}
+		if got := tc.Elapsed(); got != test.elapsed {
+			t.Errorf("%d: %s: got elapsed %v, want %v", i, test.name, got, test.elapsed)
+		}
+		if test.location != "" {
+			loc, _ := tc.Location()
+			if got := loc.String(); got != test.location {
+				t.Errorf("%d: %s: got location %s, want %s", i, test.name, got, test.location)
+			}
+		}
+		if test.message != "" {
+			if got := tc.Message(); got != test.message {
+				t.Errorf("%d: %s: got message %q, want %q", i, test.name, got, test.message)
+			}
+		}
+	}
+
+	if got, want := len(pkg.Tests), len(tt); got != want {
+		t.Errorf("got %d tests, want %d", got, want)
+	}
+
+	// The output of a check is its own, not that of TestPackage.
+	if out := pkg.GetTest("FooSuite.TestOk").Output(); !strings.Contains(out, "DEBUG juju.machine connecting") {
+		t.Errorf("FooSuite.TestOk: log missing from output %q", out)
+	}
+	if out := pkg.GetTest("TestPackage").Output(); strings.Contains(out, "obtained int") {
+		t.Errorf("TestPackage: got failure of a check in output %q", out)
+	}
+}
@@ -0,0 +1,121 @@
+package parse
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// TestHistory aggregates the results of a single test across runs.
+type TestHistory struct {
+	Package string
+	Name    string
+
+	// Runs is the number of runs in which the test passed or failed. Runs in which
+	// it was skipped are not counted.
+	Runs     int
+	Failures int
+
+	// Mean and StdDev of the elapsed time, in seconds, over all counted runs.
+	Mean, StdDev float64
+
+	// LastFailed is the time of the most recent failure, or zero.
+	LastFailed time.Time
+}
+
+// FailureRate returns the fraction of runs in which the test failed.
+func (h *TestHistory) FailureRate() float64 {
+	if h.Runs == 0 {
+		return 0
+	}
+	return float64(h.Failures) / float64(h.Runs)
+}
+
+// Aggregate computes the history of every test across runs, each run being the
+// packages parsed from one go test invocation. Tests are sorted by package and name.
+// Tests of a panicked package that did not complete, including the one that panicked,
+// count as failed.
+func Aggregate(runs []Packages) []*TestHistory {
+	type key struct{ pkg, name string }
+	index := make(map[key]*TestHistory)
+	durations := make(map[key][]float64)
+
+	for _, pkgs := range runs {
+		for name, pkg := range pkgs {
+			panicked := pkg.HasPanic
+			for _, t := range pkg.Tests {
+				if t.Name == "" {
+					continue
+				}
+				status := t.Status()
+				if status == ActionSkip {
+					continue
+				}
+				if pkg.HasPanic && t.Name == pkg.Summary.Test {
+					panicked = false
+				}
+
+				k := key{name, t.Name}
+				h, ok := index[k]
+				if !ok {
+					h = &TestHistory{Package: name, Name: t.Name}
+					index[k] = h
+				}
+				h.Runs++
+				durations[k] = append(durations[k], t.Elapsed())
+
+				if status == ActionFail {
+					h.Failures++
+					if n := len(t.Events); n > 0 && t.Events[n-1].Time.After(h.LastFailed) {
+						h.LastFailed = t.Events[n-1].Time
+					}
+				}
+			}
+
+			// A panic that stopped the test binary before the test that panicked was
+			// recorded, or outside of tests, fails it all the same.
+			if panicked {
+				k := key{name, pkg.PanicTest()}
+				h, ok := index[k]
+				if !ok {
+					h = &TestHistory{Package: name, Name: k.name}
+					index[k] = h
+				}
+				h.Runs++
+				h.Failures++
+				durations[k] = append(durations[k], 0)
+				if pkg.Summary.Time.After(h.LastFailed) {
+					h.LastFailed = pkg.Summary.Time
+				}
+			}
+		}
+	}
+
+	history := make([]*TestHistory, 0, len(index))
+	for k, h := range index {
+		h.Mean, h.StdDev = meanStdDev(durations[k])
+		history = append(history, h)
+	}
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].Package != history[j].Package {
+			return history[i].Package < history[j].Package
+		}
+		return history[i].Name < history[j].Name
+	})
+	return history
+}
+
+// meanStdDev returns the mean and population standard deviation of values.
+func meanStdDev(values []float64) (mean, stddev float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		stddev += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(stddev / float64(len(values)))
+}
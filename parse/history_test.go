@@ -0,0 +1,129 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAggregate(t *testing.T) {
+
+	t.Parallel()
+
+	// TestCatch fails in input02.json and passes in the later rerun/input01.json.
+	var runs []Packages
+	for _, name := range []string{
+		filepath.Join("testdata", "big", "input02.json"),
+		filepath.Join("testdata", "rerun", "input01.json"),
+	} {
+		by, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkgs, err := Process(bytes.NewReader(by))
+		if err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, pkgs)
+	}
+
+	var catch *TestHistory
+	for _, h := range Aggregate(runs) {
+		if h.Name == "TestCatch" {
+			catch = h
+		}
+	}
+	if catch == nil {
+		t.Fatal("no history for TestCatch")
+	}
+
+	if catch.Runs != 2 || catch.Failures != 1 {
+		t.Errorf("got %d runs and %d failures, want 2 and 1", catch.Runs, catch.Failures)
+	}
+	if got := catch.FailureRate(); got != 0.5 {
+		t.Errorf("got failure rate %v, want 0.5", got)
+	}
+	want, err := time.Parse(time.RFC3339Nano, "2018-10-28T00:06:54.507544-04:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !catch.LastFailed.Equal(want) {
+		t.Errorf("got last failed %v, want %v", catch.LastFailed, want)
+	}
+}
+
+func TestAggregatePanic(t *testing.T) {
+
+	t.Parallel()
+
+	// TestCrash passes, then panics; the second run also panics in init.
+	inputs := []string{
+		`{"Action":"pass","Package":"example.com/fs","Test":"TestCrash","Elapsed":0.1}
+{"Action":"pass","Package":"example.com/fs"}
+`,
+		`{"Action":"run","Package":"example.com/fs","Test":"TestCrash"}
+{"Action":"output","Package":"example.com/fs","Test":"TestCrash","Output":"panic: boom\n"}
+{"Action":"fail","Package":"example.com/fs"}
+`,
+		`{"Action":"output","Package":"example.com/fs","Output":"panic: init\n"}
+{"Action":"fail","Package":"example.com/fs"}
+`,
+	}
+	var runs []Packages
+	for _, input := range inputs {
+		pkgs, err := Process(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, pkgs)
+	}
+
+	tt := []struct {
+		name           string
+		runs, failures int
+	}{
+		// 0
+		{"TestCrash", 2, 1},
+		// 1
+		{"[panic]", 1, 1},
+	}
+	history := Aggregate(runs)
+	if len(history) != len(tt) {
+		t.Fatalf("got %d tests, want %d", len(history), len(tt))
+	}
+	for i, test := range tt {
+		h := history[i]
+		if h.Name != test.name || h.Runs != test.runs || h.Failures != test.failures {
+			t.Errorf("%d: got %s with %d runs and %d failures, want %s with %d and %d",
+				i, h.Name, h.Runs, h.Failures, test.name, test.runs, test.failures)
+		}
+	}
+}
+
+func TestMeanStdDev(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		values       []float64
+		mean, stddev float64
+	}{
+		{nil, 0, 0},          // 0
+		{[]float64{1}, 1, 0}, // 1
+		{[]float64{2, 4, 4, 4, 5, 5, 7, 9}, 5, 2}, // 2
+	}
+
+	for i, test := range tt {
+		t.Run(fmt.Sprintf("values_%d", i), func(t *testing.T) {
+			mean, stddev := meanStdDev(test.values)
+			if math.Abs(mean-test.mean) > 1e-9 || math.Abs(stddev-test.stddev) > 1e-9 {
+				t.Errorf("got %v ± %v, want %v ± %v", mean, stddev, test.mean, test.stddev)
+			}
+		})
+	}
+}
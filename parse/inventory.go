@@ -0,0 +1,57 @@
+package parse
+
+import "sort"
+
+// AddedTests returns the names of the tests in run that are not in baseline, keyed by
+// package and sorted. All tests of packages missing from baseline are added. Packages
+// that panicked in baseline are left out, as their tests are not all known.
+func AddedTests(baseline, run Packages) map[string][]string {
+	added := make(map[string][]string)
+	for name, pkg := range run {
+		base, ok := baseline[name]
+		if ok && base.HasPanic {
+			continue
+		}
+		if names := missingTests(pkg, base); len(names) > 0 {
+			added[name] = names
+		}
+	}
+	return added
+}
+
+// RemovedTests returns the names of the tests in baseline that are not in run, keyed
+// by package and sorted: tests that were removed, renamed or no longer matched by -run.
+// Only packages in both are compared, as run may cover fewer packages, and packages that
+// panicked in run are left out.
+func RemovedTests(baseline, run Packages) map[string][]string {
+	removed := make(map[string][]string)
+	for name, pkg := range run {
+		base, ok := baseline[name]
+		if !ok || pkg.HasPanic {
+			continue
+		}
+		if names := missingTests(base, pkg); len(names) > 0 {
+			removed[name] = names
+		}
+	}
+	return removed
+}
+
+// missingTests returns the sorted names of the tests of pkg that are not in other,
+// which may be nil.
+func missingTests(pkg, other *Package) []string {
+	known := make(map[string]bool)
+	if other != nil {
+		for _, t := range other.Tests {
+			known[t.Name] = true
+		}
+	}
+	var names []string
+	for _, t := range pkg.Tests {
+		if t.Name != "" && !known[t.Name] {
+			names = append(names, t.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,83 @@
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAddedTests(t *testing.T) {
+
+	t.Parallel()
+
+	baseline := `{"Action":"pass","Package":"example.com/a","Test":"TestOld"}
+{"Action":"pass","Package":"example.com/a"}
+{"Action":"output","Package":"example.com/c","Output":"panic: boom\n"}
+{"Action":"fail","Package":"example.com/c"}
+`
+	run := `{"Action":"pass","Package":"example.com/a","Test":"TestOld"}
+{"Action":"pass","Package":"example.com/a","Test":"TestNew/sub"}
+{"Action":"pass","Package":"example.com/a","Test":"TestNew"}
+{"Action":"pass","Package":"example.com/a"}
+{"Action":"fail","Package":"example.com/b","Test":"TestB"}
+{"Action":"fail","Package":"example.com/b"}
+{"Action":"pass","Package":"example.com/c","Test":"TestC"}
+{"Action":"pass","Package":"example.com/c"}
+`
+
+	base, err := Process(strings.NewReader(baseline))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkgs, err := Process(strings.NewReader(run))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := AddedTests(base, pkgs)
+	want := map[string][]string{
+		"example.com/a": {"TestNew", "TestNew/sub"},
+		"example.com/b": {"TestB"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestRemovedTests(t *testing.T) {
+
+	t.Parallel()
+
+	baseline := `{"Action":"pass","Package":"example.com/a","Test":"TestOld"}
+{"Action":"pass","Package":"example.com/a","Test":"TestKept"}
+{"Action":"pass","Package":"example.com/a"}
+{"Action":"pass","Package":"example.com/b","Test":"TestB"}
+{"Action":"pass","Package":"example.com/b"}
+{"Action":"pass","Package":"example.com/c","Test":"TestC"}
+{"Action":"pass","Package":"example.com/c"}
+`
+	run := `{"Action":"pass","Package":"example.com/a","Test":"TestKept"}
+{"Action":"pass","Package":"example.com/a","Test":"TestRenamed"}
+{"Action":"pass","Package":"example.com/a"}
+{"Action":"output","Package":"example.com/c","Output":"panic: boom\n"}
+{"Action":"fail","Package":"example.com/c"}
+`
+
+	base, err := Process(strings.NewReader(baseline))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkgs, err := Process(strings.NewReader(run))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// example.com/b did not run and example.com/c panicked, so neither is compared.
+	got := RemovedTests(base, pkgs)
+	want := map[string][]string{
+		"example.com/a": {"TestOld"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,255 @@
+package parse
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// IsJUnit reports whether line, the first line of some input, starts a JUnit XML
+// report rather than go test -json events.
+func IsJUnit(line string) bool {
+	line = strings.TrimLeft(line, "\ufeff \t\r\n")
+	return strings.HasPrefix(line, "<?xml") || strings.HasPrefix(line, "<testsuite")
+}
+
+// JUnit XML, as written by tparse, go-junit-report, gotestsum and the test runners
+// of other languages. Suites may be nested.
+
+type junitSuites struct {
+	Timestamp string       `xml:"timestamp,attr"`
+	Suites    []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string       `xml:"name,attr"`
+	Time      string       `xml:"time,attr"`
+	Timestamp string       `xml:"timestamp,attr"`
+	Tests     string       `xml:"tests,attr"`
+	Failures  string       `xml:"failures,attr"`
+	Errors    string       `xml:"errors,attr"`
+	Suites    []junitSuite `xml:"testsuite"`
+	Cases     []junitCase  `xml:"testcase"`
+	SystemOut string       `xml:"system-out"`
+	SystemErr string       `xml:"system-err"`
+}
+
+type junitCase struct {
+	ClassName string         `xml:"classname,attr"`
+	Name      string         `xml:"name,attr"`
+	Time      string         `xml:"time,attr"`
+	Failures  []junitMessage `xml:"failure"`
+	Errors    []junitMessage `xml:"error"`
+	Skipped   *junitMessage  `xml:"skipped"`
+	SystemOut string         `xml:"system-out"`
+	SystemErr string         `xml:"system-err"`
+}
+
+type junitMessage struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// junitTimestampLayouts are the layouts of suite timestamps, which often lack a zone.
+var junitTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+}
+
+// ConvertJUnit converts the JUnit XML report read from r into go test -json events
+// written to w, so it can be processed as the output of go test. Each test suite
+// becomes a package of that name, and each test case a test. Test cases are named
+// by their class name too, as in pkg.Class.test, unless it names the package.
+func ConvertJUnit(w io.Writer, r io.Reader) error {
+	dec := xml.NewDecoder(r)
+	var suites []junitSuite
+	var timestamp string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return errors.New("not a JUnit XML report: no testsuites or testsuite element")
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to decode JUnit XML")
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "testsuites":
+			var v junitSuites
+			err = dec.DecodeElement(&v, &start)
+			suites, timestamp = v.Suites, v.Timestamp
+		case "testsuite":
+			var v junitSuite
+			err = dec.DecodeElement(&v, &start)
+			suites = []junitSuite{v}
+		default:
+			return fmt.Errorf("not a JUnit XML report: unexpected %s element", start.Name.Local)
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to decode JUnit XML")
+		}
+		break
+	}
+
+	enc := json.NewEncoder(w)
+	for _, s := range flattenSuites(suites, timestamp) {
+		if err := convertSuite(enc, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// flattenSuites returns the suites holding test cases, including nested ones. Suites
+// without a timestamp get the one of the enclosing suite, timestamp.
+func flattenSuites(suites []junitSuite, timestamp string) []junitSuite {
+	var flat []junitSuite
+	for _, s := range suites {
+		if s.Timestamp == "" {
+			s.Timestamp = timestamp
+		}
+		if len(s.Cases) > 0 || len(s.Suites) == 0 {
+			flat = append(flat, s)
+		}
+		flat = append(flat, flattenSuites(s.Suites, s.Timestamp)...)
+	}
+	return flat
+}
+
+// convertSuite writes the events of the suite s, running its test cases one after the
+// other from its timestamp. Without a timestamp, events have no time either.
+func convertSuite(enc *json.Encoder, s junitSuite) error {
+	pkg := s.Name
+	if pkg == "" && len(s.Cases) > 0 {
+		pkg = s.Cases[0].ClassName
+	}
+	if pkg == "" {
+		pkg = "junit"
+	}
+
+	var start time.Time
+	for _, layout := range junitTimestampLayouts {
+		if t, err := time.Parse(layout, s.Timestamp); err == nil {
+			start = t
+			break
+		}
+	}
+	now := start
+
+	var events []jsonEvent
+	emit := func(action Action, test, output string, elapsed float64) {
+		e := jsonEvent{
+			Action:  action,
+			Package: pkg,
+			Test:    test,
+			Output:  output,
+			Elapsed: elapsed,
+		}
+		if !start.IsZero() {
+			e.Time = now
+		}
+		events = append(events, e)
+	}
+	outputLines := func(test, s string) {
+		s = strings.Trim(s, "\n")
+		if strings.TrimSpace(s) == "" {
+			return
+		}
+		for _, line := range strings.Split(s, "\n") {
+			emit(ActionOutput, test, line+"\n", 0)
+		}
+	}
+
+	failed := false
+	var total float64
+	for _, c := range s.Cases {
+		name := c.Name
+		if c.ClassName != "" && c.ClassName != pkg && c.ClassName != path.Base(pkg) {
+			name = c.ClassName + "." + name
+		}
+		elapsed := junitSeconds(c.Time)
+
+		emit(ActionRun, name, "", 0)
+		emit(ActionOutput, name, "=== RUN   "+name+"\n", 0)
+		outputLines(name, c.SystemOut)
+		outputLines(name, c.SystemErr)
+
+		action := ActionPass
+		for _, m := range append(c.Failures, c.Errors...) {
+			action = ActionFail
+			if strings.TrimSpace(m.Contents) != "" {
+				outputLines(name, m.Contents)
+			} else {
+				outputLines(name, "    "+m.Message)
+			}
+		}
+		if c.Skipped != nil && action == ActionPass {
+			action = ActionSkip
+			outputLines(name, "    "+c.Skipped.Message)
+		}
+
+		now = now.Add(time.Duration(elapsed * float64(time.Second)))
+		emit(ActionOutput, name, fmt.Sprintf("--- %s: %s (%.2fs)\n", strings.ToUpper(string(action)), name, elapsed), 0)
+		emit(action, name, "", elapsed)
+		if action == ActionFail {
+			failed = true
+		}
+		total += elapsed
+	}
+
+	outputLines("", s.SystemOut)
+	outputLines("", s.SystemErr)
+
+	elapsed := total
+	if s.Time != "" {
+		elapsed = junitSeconds(s.Time)
+	}
+	now = start.Add(time.Duration(elapsed * float64(time.Second)))
+	// A suite may declare tests without listing them, e.g. when truncated.
+	declared, _ := strconv.Atoi(strings.TrimSpace(s.Tests))
+	failures, _ := strconv.Atoi(strings.TrimSpace(s.Failures))
+	errs, _ := strconv.Atoi(strings.TrimSpace(s.Errors))
+	if len(s.Cases) == 0 && declared > 0 {
+		emit(ActionOutput, "", fmt.Sprintf("testsuite declares %d tests but lists none\n", declared), 0)
+		failed = failures+errs > 0
+	}
+
+	switch {
+	case len(s.Cases) == 0 && declared == 0:
+		emit(ActionOutput, "", "?   \t"+pkg+"\t[no test files]\n", 0)
+		emit(ActionSkip, "", "", 0)
+	case failed:
+		emit(ActionOutput, "", "FAIL\n", 0)
+		emit(ActionOutput, "", fmt.Sprintf("FAIL\t%s\t%.3fs\n", pkg, elapsed), 0)
+		emit(ActionFail, "", "", elapsed)
+	default:
+		emit(ActionOutput, "", "PASS\n", 0)
+		emit(ActionOutput, "", fmt.Sprintf("ok  \t%s\t%.3fs\n", pkg, elapsed), 0)
+		emit(ActionPass, "", "", elapsed)
+	}
+
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// junitSeconds parses a time attribute, which some runners write with thousands
+// separators. Invalid times are 0.
+func junitSeconds(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.Replace(strings.TrimSpace(s), ",", "", -1), 64)
+	return f
+}
@@ -0,0 +1,204 @@
+package parse
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsJUnit(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		line string
+		want bool
+	}{
+		// 0
+		{`<?xml version="1.0" encoding="UTF-8"?>`, true},
+		// 1
+		{"  <testsuites>\n", true},
+		// 2
+		{`<testsuite name="example.com/calc">`, true},
+		// 3
+		{`{"Action":"run","Package":"example.com/calc"}`, false},
+		// 4
+		{"=== RUN   TestAdd\n", false},
+	}
+
+	for i, test := range tt {
+		if got := IsJUnit(test.line); got != test.want {
+			t.Errorf("%d: IsJUnit(%q) = %v, want %v", i, test.line, got, test.want)
+		}
+	}
+}
+
+func TestConvertJUnit(t *testing.T) {
+
+	t.Parallel()
+
+	// input01.xml holds a Go package with a failure, a subtest and a skip, nested
+	// suites from another language with an error, and a suite without test cases.
+	f, err := os.Open(filepath.Join("testdata", "junit", "input01.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	if err := ConvertJUnit(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	pkgs, err := Process(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pkgs) != 3 {
+		t.Fatalf("got %d packages, want 3", len(pkgs))
+	}
+
+	tt := []struct {
+		pkg              string
+		action           Action
+		elapsed          float64
+		pass, fail, skip int
+	}{
+		// 0
+		{"example.com/calc", ActionFail, 0.75, 2, 1, 1},
+		// 1
+		{"tests", ActionFail, 0.75, 1, 1, 0},
+	}
+
+	for i, test := range tt {
+		pkg, ok := pkgs[test.pkg]
+		if !ok {
+			t.Fatalf("%d: missing package %s", i, test.pkg)
+		}
+		if pkg.Summary.Action != test.action {
+			t.Errorf("%d: got action %s, want %s", i, pkg.Summary.Action, test.action)
+		}
+		if pkg.Summary.Elapsed != test.elapsed {
+			t.Errorf("%d: got elapsed %v, want %v", i, pkg.Summary.Elapsed, test.elapsed)
+		}
+		pass := len(pkg.TestsByAction(ActionPass))
+		fail := len(pkg.TestsByAction(ActionFail))
+		skip := len(pkg.TestsByAction(ActionSkip))
+		if pass != test.pass || fail != test.fail || skip != test.skip {
+			t.Errorf("%d: got pass/fail/skip %d/%d/%d, want %d/%d/%d", i,
+				pass, fail, skip, test.pass, test.fail, test.skip)
+		}
+	}
+
+	if !pkgs["example.com/empty"].NoTestFiles {
+		t.Error("example.com/empty: want no test files")
+	}
+
+	divide := pkgs["example.com/calc"].GetTest("TestDivide")
+	if divide == nil {
+		t.Fatal("missing TestDivide")
+	}
+	if got, want := divide.Elapsed(), 0.2; got != want {
+		t.Errorf("TestDivide: got elapsed %v, want %v", got, want)
+	}
+	if !strings.Contains(divide.Output(), "calc_test.go:21: division by zero") {
+		t.Errorf("TestDivide: failure missing from output %q", divide.Output())
+	}
+
+	post := pkgs["tests"].GetTest("tests.test_api.test_post")
+	if post == nil {
+		t.Fatal("missing tests.test_api.test_post")
+	}
+	if !strings.Contains(post.Output(), "ConnectionError: refused") {
+		t.Errorf("test_post: error missing from output %q", post.Output())
+	}
+}
+
+func TestConvertJUnitSuites(t *testing.T) {
+
+	t.Parallel()
+
+	// input02.xml holds suites inheriting the timestamp of testsuites, and suites
+	// declaring tests without listing them.
+	f, err := os.Open(filepath.Join("testdata", "junit", "input02.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	if err := ConvertJUnit(&buf, f); err != nil {
+		t.Fatal(err)
+	}
+	pkgs, err := Process(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		pkg     string
+		action  Action
+		started string
+	}{
+		// 0
+		{"example.com/inherit", ActionPass, "2021-03-01T10:00:00Z"},
+		// 1
+		{"example.com/own", ActionPass, "2021-03-01T11:00:00Z"},
+		// 2, ending after its time of 1s
+		{"example.com/unlisted", ActionPass, "2021-03-01T10:00:01Z"},
+		// 3
+		{"example.com/unlisted-failed", ActionFail, "2021-03-01T10:00:01Z"},
+	}
+
+	for i, test := range tt {
+		pkg, ok := pkgs[test.pkg]
+		if !ok {
+			t.Fatalf("%d: missing package %s", i, test.pkg)
+		}
+		if pkg.Summary.Action != test.action {
+			t.Errorf("%d: got action %s, want %s", i, pkg.Summary.Action, test.action)
+		}
+		if pkg.NoTestFiles {
+			t.Errorf("%d: got no test files for a suite with tests", i)
+		}
+		if got := pkg.Started.Format(time.RFC3339); got != test.started {
+			t.Errorf("%d: got start %s, want %s", i, got, test.started)
+		}
+	}
+
+	// Without any timestamp, events have no time rather than the time of conversion.
+	buf.Reset()
+	in := `<testsuite name="example.com/notime" tests="1"><testcase classname="example.com/notime" name="TestA"/></testsuite>`
+	if err := ConvertJUnit(&buf, strings.NewReader(in)); err != nil {
+		t.Fatal(err)
+	}
+	if pkgs, err = Process(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if pkg := pkgs["example.com/notime"]; pkg == nil || !pkg.Summary.Time.IsZero() {
+		t.Errorf("got package %+v, want one without time", pkg)
+	}
+}
+
+func TestConvertJUnitInvalid(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []string{
+		// 0
+		`<?xml version="1.0"?><html></html>`,
+		// 1
+		`<?xml version="1.0"?>`,
+		// 2
+		`<testsuites><testsuite>`,
+	}
+
+	for i, input := range tt {
+		if err := ConvertJUnit(new(bytes.Buffer), strings.NewReader(input)); err == nil {
+			t.Errorf("%d: want error for %q", i, input)
+		}
+	}
+}
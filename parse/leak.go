@@ -0,0 +1,122 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Leak is a goroutine reported by goleak ("found unexpected goroutines"). Leaked
+// goroutines of a test with the same creation site are reported as a single Leak.
+type Leak struct {
+	// Test is the test that reported the leak, or empty if it was reported outside of
+	// any test, as with goleak.VerifyTestMain.
+	Test string
+	// Count is the number of leaked goroutines created at the same site.
+	Count int
+	// State is the state of the goroutine, e.g., "chan receive".
+	State string
+	// Function is the function at the top of the stack.
+	Function string
+	// CreatedBy is the function that started the goroutine.
+	CreatedBy string
+	// Location is the file and line of the go statement, if known.
+	Location string
+}
+
+var (
+	goroutineRe = regexp.MustCompile(`^goroutine \d+ \[([^\]]+)\]:$`)
+	frameArgsRe = regexp.MustCompile(`\(.*\)$`)
+)
+
+const leakHeader = "found unexpected goroutines"
+
+// Leaks returns the goroutine leaks reported in the output of tests in the package,
+// and in package output outside of tests, in order of appearance.
+func (p *Package) Leaks() []Leak {
+	var leaks []Leak
+	for _, t := range p.Tests {
+		leaks = append(leaks, parseLeaks(t.Name, t.Events)...)
+	}
+	return append(leaks, parseLeaks("", p.Unattributed)...)
+}
+
+// parseLeaks parses goroutine stacks following a goleak report in the output events,
+// grouping them by creation site.
+func parseLeaks(test string, events Events) []Leak {
+	var leaks []Leak
+	index := make(map[string]int)
+
+	var (
+		scan    bool
+		current *Leak
+		next    string // what the next stack line holds: "function" or "created"
+	)
+	flush := func() {
+		if current == nil {
+			return
+		}
+		key := current.CreatedBy + "@" + current.Location
+		if current.CreatedBy == "" {
+			key = current.Function
+		}
+		if i, ok := index[key]; ok {
+			leaks[i].Count++
+		} else {
+			index[key] = len(leaks)
+			leaks = append(leaks, *current)
+		}
+		current = nil
+	}
+
+	for _, e := range events {
+		if e.Action != ActionOutput {
+			continue
+		}
+		for _, line := range strings.Split(e.Output, "\n") {
+			line = strings.TrimSpace(line)
+			if strings.Contains(line, leakHeader) {
+				scan = true
+				continue
+			}
+			if !scan || line == "" {
+				continue
+			}
+
+			if m := goroutineRe.FindStringSubmatch(line); m != nil {
+				flush()
+				current = &Leak{Test: test, Count: 1, State: m[1]}
+				next = "function"
+				continue
+			}
+			if current == nil {
+				continue
+			}
+
+			switch {
+			case strings.HasPrefix(line, "created by "):
+				created := strings.TrimPrefix(line, "created by ")
+				// Go 1.21 and later append the parent goroutine.
+				if i := strings.Index(created, " in goroutine "); i >= 0 {
+					created = created[:i]
+				}
+				current.CreatedBy = created
+				next = "created"
+			case next == "function":
+				current.Function = frameArgsRe.ReplaceAllString(line, "")
+				next = ""
+			case next == "created":
+				// The file and line of the go statement, followed by the pc offset.
+				if i := strings.Index(line, " +0x"); i >= 0 {
+					line = line[:i]
+				}
+				current.Location = line
+				next = ""
+			case line == "]" || strings.HasPrefix(line, "--- "):
+				flush()
+			}
+		}
+	}
+	flush()
+
+	return leaks
+}
@@ -0,0 +1,60 @@
+package parse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLeaks(t *testing.T) {
+
+	t.Parallel()
+
+	// input01.json contains a goleak.VerifyNone failure in TestLeak with two goroutines
+	// created at the same site, and a goleak.VerifyTestMain failure outside of tests.
+	by, err := ioutil.ReadFile(filepath.Join("testdata", "leak", "input01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := pkgs["example.com/leaky"].Leaks()
+	want := []Leak{
+		// 0
+		{
+			Test:      "TestLeak",
+			Count:     2,
+			State:     "chan receive",
+			Function:  "example.com/leaky.worker",
+			CreatedBy: "example.com/leaky.Start",
+			Location:  "/src/leaky/leaky.go:9",
+		},
+		// 1
+		{
+			Test:      "TestLeak",
+			Count:     1,
+			State:     "select",
+			Function:  "example.com/leaky.poll",
+			CreatedBy: "example.com/leaky.Watch",
+			Location:  "/src/leaky/watch.go:30",
+		},
+		// 2
+		{
+			Count:     1,
+			State:     "IO wait",
+			Function:  "internal/poll.runtime_pollWait",
+			CreatedBy: "net/http.(*Transport).dialConn",
+			Location:  "/usr/local/go/src/net/http/transport.go:1776",
+		},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got leaks\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,202 @@
+package parse
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Location is a source file and line reference, as printed by t.Log, t.Error and
+// friends: "catch_test.go:29: got id ..."
+type Location struct {
+	File string
+	Line int
+}
+
+func (l Location) String() string {
+	return l.File + ":" + strconv.Itoa(l.Line)
+}
+
+var locationRe = regexp.MustCompile(`([\w\-./\\~]+\.go):(\d+)`)
+
+// Locations returns all source locations found in s, in order of appearance.
+func Locations(s string) []Location {
+	var locs []Location
+	for _, m := range locationRe.FindAllStringSubmatch(s, -1) {
+		line, err := strconv.Atoi(m[2])
+		if err != nil {
+			continue
+		}
+		locs = append(locs, Location{File: m[1], Line: line})
+	}
+	return locs
+}
+
+// ReplaceLocations returns a copy of s with every source location replaced by the
+// result of fn.
+func ReplaceLocations(s string, fn func(Location) string) string {
+	return locationRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := locationRe.FindStringSubmatch(m)
+		line, err := strconv.Atoi(sub[2])
+		if err != nil {
+			return m
+		}
+		return fn(Location{File: sub[1], Line: line})
+	})
+}
+
+// Location returns the source location of the last line logged by a failed or skipped
+// test, typically the file and line of the t.Fatal or t.Skip that ended it. For
+// testify assertions, this is the base name of the file in the error trace of the
+// first failed assertion, as the testing package would print it. Otherwise, such as
+// for a panic, it is the first source location referenced in the output.
+func (t *Test) Location() (Location, bool) {
+	if as := t.Assertions(); len(as) > 0 && as[0].Location.File != "" {
+		loc := as[0].Location
+		loc.File = path.Base(filepath.ToSlash(loc.File))
+		return loc, true
+	}
+
+	stack := t.Stack()
+	if loc, _, ok := t.lastLogEntry(stack); ok {
+		return loc, true
+	}
+	locs := Locations(stack)
+	if len(locs) == 0 {
+		locs = Locations(t.logOutput())
+	}
+	if len(locs) == 0 {
+		return Location{}, false
+	}
+	// A panic stack starts in the runtime, rather than in the test.
+	for _, loc := range locs {
+		if !strings.Contains(filepath.ToSlash(loc.File), "/src/runtime/") {
+			return loc, true
+		}
+	}
+	return locs[0], true
+}
+
+// Message returns the summary of the first failed testify assertion or else the last
+// line logged by the test, the one at its Location, stripped of its source location.
+// For output without log lines, such as a panic, it is the first line following the
+// "--- FAIL" or "--- SKIP" report line. Returns an empty string if there is no such
+// line.
+func (t *Test) Message() string {
+	if as := t.Assertions(); len(as) > 0 {
+		return as[0].Summary()
+	}
+
+	stack := t.Stack()
+	if _, msg, ok := t.lastLogEntry(stack); ok {
+		return msg
+	}
+	lines := strings.Split(stack, "\n")
+	if msg := firstMessage(lines[1:]); msg != "" {
+		return msg
+	}
+	return firstMessage(strings.Split(t.logOutput(), "\n"))
+}
+
+// SkipReason returns the message passed to t.Skip or t.Skipf by a skipped test, or an
+// empty string if the test was not skipped or gave no reason.
+func (t *Test) SkipReason() string {
+	if t.Status() != ActionSkip {
+		return ""
+	}
+	return t.Message()
+}
+
+// shortSkipRe matches the conventional messages of tests skipped because of
+// testing.Short(), such as "skipping in short mode" or "skipped with -short".
+var shortSkipRe = regexp.MustCompile(`(?i)\bshort mode\b|(?:^|\s)-short\b|\btesting\.Short\(\)`)
+
+// ShortSkip reports whether the test was skipped because of testing.Short(), judged by
+// its skip reason.
+func (t *Test) ShortSkip() bool {
+	return shortSkipRe.MatchString(t.SkipReason())
+}
+
+// lastLogEntry returns the location and message of the last line the test logged
+// with t.Log, t.Error, t.Skip and friends, "db_test.go:12: message". Since Go 1.14
+// these precede the report line, before that they follow it in the stack. Returns
+// false if the test panicked, leaving the panic to be reported instead.
+func (t *Test) lastLogEntry(stack string) (Location, string, bool) {
+	lines := strings.Split(stack, "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(line, "panic: ") {
+			return Location{}, "", false
+		}
+	}
+	if loc, msg, ok := lastLogEntry(strings.Split(t.logOutput(), "\n")); ok {
+		return loc, msg, true
+	}
+	return lastLogEntry(lines[1:])
+}
+
+// lastLogEntry returns the location and message of the last line in lines that starts
+// with a source location followed by a message.
+func lastLogEntry(lines []string) (Location, string, bool) {
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := strings.TrimSpace(lines[i])
+		m := locationRe.FindStringSubmatchIndex(line)
+		if m == nil || m[0] != 0 || !strings.HasPrefix(line[m[1]:], ":") {
+			continue
+		}
+		msg := strings.TrimSpace(line[m[1]+1:])
+		if msg == "" {
+			// A location on a line of its own, as gocheck prints it.
+			continue
+		}
+		n, err := strconv.Atoi(line[m[4]:m[5]])
+		if err != nil {
+			continue
+		}
+		return Location{File: line[m[2]:m[3]], Line: n}, msg, true
+	}
+	return Location{}, "", false
+}
+
+// firstMessage returns the first non-empty line that is not a report line, stripped of
+// its source location.
+func firstMessage(lines []string) string {
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if _, ok := reportName(line); ok {
+			// A nested report of a failed subtest.
+			continue
+		}
+		if loc := locationRe.FindStringIndex(line); loc != nil && loc[0] == 0 {
+			line = strings.TrimSpace(strings.TrimPrefix(line[loc[1]:], ":"))
+		}
+		if line == "" {
+			// A location on a line of its own, as gocheck prints it.
+			continue
+		}
+		return line
+	}
+	return ""
+}
+
+// logOutput returns the output of the test preceding its report line. Since Go 1.14
+// the report line follows the log output of the test, instead of preceding it.
+func (t *Test) logOutput() string {
+	t.SortEvents()
+
+	var out strings.Builder
+	for _, e := range t.Events {
+		if e.Action != ActionOutput || strings.HasPrefix(e.Output, "=== ") {
+			continue
+		}
+		if _, ok := reportName(strings.TrimSpace(e.Output)); ok {
+			break
+		}
+		out.WriteString(e.Output)
+	}
+	return out.String()
+}
@@ -0,0 +1,250 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestLocations(t *testing.T) {
+
+	t.Parallel()
+
+	got := Locations("    catch_test.go:29: got id\n\t/home/me/go/src/pkg/some_test.go:8 +0x88\nno location here: 12\n")
+	want := []Location{
+		{File: "catch_test.go", Line: 29},
+		{File: "/home/me/go/src/pkg/some_test.go", Line: 8},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestReplaceLocations(t *testing.T) {
+
+	t.Parallel()
+
+	got := ReplaceLocations("    catch_test.go:29: got id\nno location here: 12\n", func(loc Location) string {
+		return "<" + loc.File + "@" + strconv.Itoa(loc.Line) + ">"
+	})
+	want := "    <catch_test.go@29>: got id\nno location here: 12\n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTestLocation(t *testing.T) {
+
+	t.Parallel()
+
+	// input02.json contains a single failed subtest TestCatch/catchAndRetrieve.
+	by, err := ioutil.ReadFile(filepath.Join("testdata", "big", "input02.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := pkgs["github.com/astromail/rover/tests"].GetTest("TestCatch/catchAndRetrieve")
+
+	loc, ok := tc.Location()
+	if !ok {
+		t.Fatal("got no location")
+	}
+	// The subtest reports three errors; the last one ended it.
+	if want := (Location{File: "catch_test.go", Line: 41}); loc != want {
+		t.Errorf("got location %v, want %v", loc, want)
+	}
+	if got, want := tc.Message(), `failed to mark email as read: failed to mark email id "123" as read: PUT "http://localhost:8026/api/v1/emails/123/read": expecting valid status code, got 500 Internal Server Error`; got != want {
+		t.Errorf("got message %q, want %q", got, want)
+	}
+
+	parent := pkgs["github.com/astromail/rover/tests"].GetTest("TestCatch")
+	if _, ok := parent.Location(); ok {
+		t.Error("got location for parent test, want none")
+	}
+	if got := parent.Message(); got != "" {
+		t.Errorf("got message %q for parent test, want empty", got)
+	}
+}
+
+func TestTestLocationLogOutput(t *testing.T) {
+
+	t.Parallel()
+
+	// Since Go 1.14 the log output of a test precedes its "--- FAIL" report line.
+	by, err := ioutil.ReadFile(filepath.Join("testdata", "assertion", "input01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := pkgs["example.com/user"].GetTest("TestPlain")
+
+	loc, ok := tc.Location()
+	if !ok {
+		t.Fatal("got no location")
+	}
+	if want := (Location{File: "plain_test.go", Line: 8}); loc != want {
+		t.Errorf("got location %v, want %v", loc, want)
+	}
+	if got, want := tc.Message(), "boom"; got != want {
+		t.Errorf("got message %q, want %q", got, want)
+	}
+}
+
+func TestTestLocationAfterLog(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		outputs []string
+	}{
+		// 0, since Go 1.14 the report line follows the log output
+		{[]string{
+			"    p_test.go:17: opening testdata/p.json\n",
+			"    p_test.go:21: got 2 packages, want 3\n",
+			"--- FAIL: TestP (0.00s)\n",
+		}},
+		// 1, before Go 1.14 the log output follows the report line
+		{[]string{
+			"--- FAIL: TestP (0.00s)\n",
+			"    p_test.go:17: opening testdata/p.json\n",
+			"    p_test.go:21: got 2 packages, want 3\n",
+		}},
+	}
+
+	for i, test := range tt {
+		t.Run(fmt.Sprintf("test_%d", i), func(t *testing.T) {
+			tc := &Test{Name: "TestP", Events: Events{{Action: ActionRun, Test: "TestP"}}}
+			for _, out := range test.outputs {
+				tc.Events = append(tc.Events, &Event{Action: ActionOutput, Test: "TestP", Output: out})
+			}
+			tc.Events = append(tc.Events, &Event{Action: ActionFail, Test: "TestP"})
+
+			loc, ok := tc.Location()
+			if !ok {
+				t.Fatal("got no location")
+			}
+			if want := (Location{File: "p_test.go", Line: 21}); loc != want {
+				t.Errorf("got location %v, want %v", loc, want)
+			}
+			if got, want := tc.Message(), "got 2 packages, want 3"; got != want {
+				t.Errorf("got message %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSkipReason(t *testing.T) {
+
+	t.Parallel()
+
+	by, err := ioutil.ReadFile(filepath.Join("testdata", "big", "input03.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		test string
+		want string
+	}{
+		{"TestCountMallocs", "skipping; GOMAXPROCS>1"}, // 0
+		{"TestFlagParser", ""},                         // 1, passed
+	}
+
+	for i, test := range tt {
+		t.Run(fmt.Sprintf("test_%d", i), func(t *testing.T) {
+			tc := pkgs["fmt"].GetTest(test.test)
+			if tc == nil {
+				t.Fatalf("test %s not found", test.test)
+			}
+			if got := tc.SkipReason(); got != test.want {
+				t.Errorf("got skip reason %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSkipReasonAfterLog(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		outputs []string
+	}{
+		// 0, since Go 1.14 the report line follows the log output
+		{[]string{
+			"    db_test.go:12: connecting to database\n",
+			"    db_test.go:14: set DATABASE_URL to run this test\n",
+			"--- SKIP: TestDB (0.00s)\n",
+		}},
+		// 1, before Go 1.14 the log output follows the report line
+		{[]string{
+			"--- SKIP: TestDB (0.00s)\n",
+			"    db_test.go:12: connecting to database\n",
+			"    db_test.go:14: set DATABASE_URL to run this test\n",
+		}},
+	}
+
+	for i, test := range tt {
+		t.Run(fmt.Sprintf("test_%d", i), func(t *testing.T) {
+			tc := &Test{Name: "TestDB", Events: Events{{Action: ActionRun, Test: "TestDB"}}}
+			for _, out := range test.outputs {
+				tc.Events = append(tc.Events, &Event{Action: ActionOutput, Test: "TestDB", Output: out})
+			}
+			tc.Events = append(tc.Events, &Event{Action: ActionSkip, Test: "TestDB"})
+
+			if got, want := tc.SkipReason(), "set DATABASE_URL to run this test"; got != want {
+				t.Errorf("got skip reason %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestShortSkip(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		output string
+		want   bool
+	}{
+		{"    db_test.go:12: skipping in short mode\n", true},             // 0
+		{"    db_test.go:12: skipping test in short mode.\n", true},       // 1
+		{"    db_test.go:12: skipped with -short\n", true},                // 2
+		{"    db_test.go:12: testing.Short() is set\n", true},             // 3
+		{"    db_test.go:12: skipping; GOMAXPROCS>1\n", false},            // 4
+		{"    db_test.go:12: shortcut not supported on windows\n", false}, // 5
+	}
+
+	for i, test := range tt {
+		t.Run(fmt.Sprintf("test_%d", i), func(t *testing.T) {
+			tc := &Test{Name: "TestDB", Events: Events{
+				{Action: ActionRun, Test: "TestDB"},
+				{Action: ActionOutput, Test: "TestDB", Output: test.output},
+				{Action: ActionOutput, Test: "TestDB", Output: "--- SKIP: TestDB (0.00s)\n"},
+				{Action: ActionSkip, Test: "TestDB"},
+			}}
+			if got := tc.ShortSkip(); got != test.want {
+				t.Errorf("got short skip %v, want %v for %q", got, test.want, test.output)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package parse
+
+import "sort"
+
+// MatrixRow holds the results of a single test across runs on several platforms.
+type MatrixRow struct {
+	Package string
+	Name    string
+
+	// Status holds the status of the test in each run, in the order of the runs, or ""
+	// if the test did not run.
+	Status []Action
+}
+
+// PlatformSpecific reports whether the test failed in some of the runs in which it
+// passed or failed, but not all of them.
+func (r *MatrixRow) PlatformSpecific() bool {
+	var pass, fail bool
+	for _, s := range r.Status {
+		switch s {
+		case ActionPass:
+			pass = true
+		case ActionFail:
+			fail = true
+		}
+	}
+	return pass && fail
+}
+
+// Matrix returns the tests that failed in at least one of the runs, each run being the
+// packages parsed from the go test output of one platform. Tests are sorted by package
+// and name. In a panicked package the test that panicked, or "[panic]" for a panic
+// outside of tests, and the tests it left unfinished are counted as failed.
+func Matrix(runs []Packages) []*MatrixRow {
+	type key struct{ pkg, name string }
+	index := make(map[key]*MatrixRow)
+
+	for i, pkgs := range runs {
+		for name, pkg := range pkgs {
+			set := func(test string, status Action) {
+				k := key{name, test}
+				row, ok := index[k]
+				if !ok {
+					row = &MatrixRow{Package: name, Name: test, Status: make([]Action, len(runs))}
+					index[k] = row
+				}
+				row.Status[i] = status
+			}
+			for _, t := range pkg.Tests {
+				if t.Name == "" {
+					continue
+				}
+				set(t.Name, t.Status())
+			}
+			if pkg.HasPanic {
+				set(pkg.PanicTest(), ActionFail)
+			}
+		}
+	}
+
+	var rows []*MatrixRow
+	for _, row := range index {
+		for _, s := range row.Status {
+			if s == ActionFail {
+				rows = append(rows, row)
+				break
+			}
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Package != rows[j].Package {
+			return rows[i].Package < rows[j].Package
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
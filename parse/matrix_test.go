@@ -0,0 +1,98 @@
+package parse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatrix(t *testing.T) {
+
+	t.Parallel()
+
+	// TestPath fails only on windows, TestNet fails on both platforms and TestOK passes
+	// on both. TestUnix runs only on linux.
+	inputs := []string{
+		// linux
+		`{"Action":"pass","Package":"example.com/fs","Test":"TestPath"}
+{"Action":"fail","Package":"example.com/fs","Test":"TestNet"}
+{"Action":"pass","Package":"example.com/fs","Test":"TestOK"}
+{"Action":"fail","Package":"example.com/fs","Test":"TestUnix"}
+{"Action":"fail","Package":"example.com/fs"}
+`,
+		// windows
+		`{"Action":"fail","Package":"example.com/fs","Test":"TestPath"}
+{"Action":"fail","Package":"example.com/fs","Test":"TestNet"}
+{"Action":"pass","Package":"example.com/fs","Test":"TestOK"}
+{"Action":"fail","Package":"example.com/fs"}
+`,
+	}
+
+	var runs []Packages
+	for _, input := range inputs {
+		pkgs, err := Process(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, pkgs)
+	}
+
+	got := Matrix(runs)
+	want := []*MatrixRow{
+		// 0
+		{Package: "example.com/fs", Name: "TestNet", Status: []Action{ActionFail, ActionFail}},
+		// 1
+		{Package: "example.com/fs", Name: "TestPath", Status: []Action{ActionPass, ActionFail}},
+		// 2
+		{Package: "example.com/fs", Name: "TestUnix", Status: []Action{ActionFail, ""}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	for i, specific := range []bool{false, true, false} {
+		if got := want[i].PlatformSpecific(); got != specific {
+			t.Errorf("%d: got platform specific %v, want %v", i, got, specific)
+		}
+	}
+}
+
+func TestMatrixPanic(t *testing.T) {
+
+	t.Parallel()
+
+	// TestCrash panics on windows, where TestOK never completes.
+	inputs := []string{
+		// linux
+		`{"Action":"pass","Package":"example.com/fs","Test":"TestCrash"}
+{"Action":"pass","Package":"example.com/fs","Test":"TestOK"}
+{"Action":"pass","Package":"example.com/fs"}
+`,
+		// windows
+		`{"Action":"run","Package":"example.com/fs","Test":"TestOK"}
+{"Action":"run","Package":"example.com/fs","Test":"TestCrash"}
+{"Action":"output","Package":"example.com/fs","Test":"TestCrash","Output":"panic: boom\n"}
+{"Action":"fail","Package":"example.com/fs"}
+`,
+	}
+
+	var runs []Packages
+	for _, input := range inputs {
+		pkgs, err := Process(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		runs = append(runs, pkgs)
+	}
+
+	got := Matrix(runs)
+	want := []*MatrixRow{
+		// 0
+		{Package: "example.com/fs", Name: "TestCrash", Status: []Action{ActionPass, ActionFail}},
+		// 1
+		{Package: "example.com/fs", Name: "TestOK", Status: []Action{ActionPass, ActionFail}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
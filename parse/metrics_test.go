@@ -56,6 +56,11 @@ func TestMetrics(t *testing.T) {
 		t.FailNow()
 	}
 
+	want := Totals{Packages: 9, Passed: 543, Skipped: 3}
+	if got := pkgs.Totals(); got != want {
+		t.Errorf("got totals %+v, want %+v", got, want)
+	}
+
 	tests := []struct {
 		name                           string
 		total, passed, skipped, failed int
@@ -159,3 +164,47 @@ func TestElapsed(t *testing.T) {
 		}
 	}
 }
+
+func TestTopLevelTests(t *testing.T) {
+
+	t.Parallel()
+
+	// This test depends on metrics_test.json, see TestMetrics. Subtests are excluded
+	// from the top-level counts.
+
+	by, err := ioutil.ReadFile("./testdata/metrics_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		passed, skipped int
+	}{
+		{"fmt", 46, 1},
+		{"strings", 103, 0},
+		{"bufio", 67, 0},
+		{"time", 117, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name+"_test", func(t *testing.T) {
+			pkg := pkgs[test.name]
+
+			pa := pkg.TopLevelTestsByAction(ActionPass)
+			if len(pa) != test.passed {
+				t.Errorf("got %d top-level passed tests in package %q, want %d", len(pa), test.name, test.passed)
+			}
+
+			sk := pkg.TopLevelTestsByAction(ActionSkip)
+			if len(sk) != test.skipped {
+				t.Errorf("got %d top-level skipped tests in package %q, want %d", len(sk), test.name, test.skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package parse
+
+import (
+	"regexp"
+	"sort"
+	"time"
+)
+
+var (
+	// --- PASS: TestName (0.01s), indented for subtests.
+	reportElapsedRe = regexp.MustCompile(`^(\s*--- (?:PASS|FAIL|SKIP): \S+ \()\d+(?:\.\d+)?s\)`)
+	// ok  	example.com/pkg	0.012s or FAIL	example.com/pkg	0.012s
+	packageElapsedRe = regexp.MustCompile(`^((?:ok  |FAIL)\t\S+\t)\d+(?:\.\d+)?s`)
+)
+
+// Normalize removes everything that varies between runs of the same tests, so that
+// reports of the packages can be compared with golden files: the times and elapsed
+// times of all events, including the durations printed in test and package result
+// lines, are zeroed, and tests are sorted by name rather than by the order in which
+// they started.
+func (p Packages) Normalize() {
+	for _, pkg := range p {
+		pkg.Started = time.Time{}
+		if pkg.Summary != nil {
+			normalizeEvents(Events{pkg.Summary})
+		}
+		normalizeEvents(pkg.NoTestSlice)
+		normalizeEvents(pkg.Unattributed)
+		normalizeEvents(pkg.PanicEvents)
+
+		for _, t := range pkg.Tests {
+			// Events are put in order while their times tell it.
+			t.SortEvents()
+			normalizeEvents(t.Events)
+			normalizeEvents(t.Previous)
+		}
+		sort.SliceStable(pkg.Tests, func(i, j int) bool {
+			return pkg.Tests[i].Name < pkg.Tests[j].Name
+		})
+	}
+}
+
+func normalizeEvents(events Events) {
+	for _, e := range events {
+		e.Time = time.Time{}
+		e.Elapsed = 0
+		e.Output = reportElapsedRe.ReplaceAllString(e.Output, "${1}0.00s)")
+		e.Output = packageElapsedRe.ReplaceAllString(e.Output, "${1}0.000s")
+	}
+}
@@ -0,0 +1,71 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPackagesNormalize(t *testing.T) {
+
+	t.Parallel()
+
+	input := `{"Time":"2021-03-01T10:00:00.1Z","Action":"run","Package":"example.com/calc","Test":"TestSub"}
+{"Time":"2021-03-01T10:00:00.2Z","Action":"run","Package":"example.com/calc","Test":"TestAdd"}
+{"Time":"2021-03-01T10:00:00.3Z","Action":"output","Package":"example.com/calc","Test":"TestAdd","Output":"--- PASS: TestAdd (0.10s)\n"}
+{"Time":"2021-03-01T10:00:00.3Z","Action":"pass","Package":"example.com/calc","Test":"TestAdd","Elapsed":0.1}
+{"Time":"2021-03-01T10:00:00.4Z","Action":"output","Package":"example.com/calc","Test":"TestSub","Output":"    --- FAIL: TestSub/neg (0.25s)\n"}
+{"Time":"2021-03-01T10:00:00.4Z","Action":"fail","Package":"example.com/calc","Test":"TestSub","Elapsed":0.3}
+{"Time":"2021-03-01T10:00:00.5Z","Action":"output","Package":"example.com/calc","Output":"FAIL\texample.com/calc\t0.412s\n"}
+{"Time":"2021-03-01T10:00:00.5Z","Action":"fail","Package":"example.com/calc","Elapsed":0.412}
+`
+	pkgs, err := Process(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkgs.Normalize()
+
+	pkg := pkgs["example.com/calc"]
+	if !pkg.Started.IsZero() {
+		t.Errorf("got started %v, want zero", pkg.Started)
+	}
+	if !pkg.Summary.Time.IsZero() || pkg.Summary.Elapsed != 0 {
+		t.Errorf("got summary time %v and elapsed %v, want zero", pkg.Summary.Time, pkg.Summary.Elapsed)
+	}
+	if got := pkgs.Wall(); got != 0 {
+		t.Errorf("got wall %v, want 0", got)
+	}
+
+	var names []string
+	for _, test := range pkg.Tests {
+		names = append(names, test.Name)
+		if test.Elapsed() != 0 {
+			t.Errorf("%s: got elapsed %v, want 0", test.Name, test.Elapsed())
+		}
+		for _, e := range test.Events {
+			if !e.Time.IsZero() {
+				t.Errorf("%s: got event time %v, want zero", test.Name, e.Time)
+			}
+		}
+	}
+	if got, want := strings.Join(names, ","), "TestAdd,TestSub"; got != want {
+		t.Errorf("got tests %s, want %s", got, want)
+	}
+
+	tt := []struct {
+		test, want string
+	}{
+		// 0
+		{"TestAdd", "--- PASS: TestAdd (0.00s)\n"},
+		// 1
+		{"TestSub", "    --- FAIL: TestSub/neg (0.00s)\n"},
+	}
+	for i, test := range tt {
+		var got string
+		for _, e := range pkg.GetTest(test.test).Events {
+			got += e.Output
+		}
+		if got != test.want {
+			t.Errorf("%d: got output %q, want %q", i, got, test.want)
+		}
+	}
+}
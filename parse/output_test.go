@@ -0,0 +1,160 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOutputLimit(t *testing.T) {
+
+	t.Parallel()
+
+	// This test depends on metrics_test.json, in which the passed test sort/TestCountSortOps
+	// has 10 output events (excluding update lines).
+
+	by, err := ioutil.ReadFile("./testdata/metrics_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		name               string
+		limit              int
+		outputs, truncated int
+	}{
+		{"unlimited", 0, 10, 0},
+		{"limit_100", 100, 10, 0},
+		{"limit_3", 3, 3, 7},
+	}
+
+	for _, test := range tt {
+
+		t.Run(test.name, func(t *testing.T) {
+			pkgs, err := Process(bytes.NewReader(by), WithOutputLimit(test.limit))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tc := pkgs["sort"].GetTest("TestCountSortOps")
+			if tc == nil {
+				t.Fatal("got no test TestCountSortOps")
+			}
+
+			var outputs int
+			for _, e := range tc.Events {
+				if e.Action == ActionOutput {
+					outputs++
+				}
+			}
+			if outputs != test.outputs {
+				t.Errorf("got %d output events, want %d", outputs, test.outputs)
+			}
+			if tc.Truncated != test.truncated {
+				t.Errorf("got %d truncated events, want %d", tc.Truncated, test.truncated)
+			}
+			// The last lines are retained.
+			if want := "    sort_test.go:635: Sort    1000000 elements:     4841165 Swap,   19995735 Less\n"; !strings.HasSuffix(tc.Output(), want) {
+				t.Errorf("got output %q, want suffix %q", tc.Output(), want)
+			}
+			// Status and elapsed time must survive truncation.
+			if tc.Status() != ActionPass || tc.Elapsed() == 0 {
+				t.Errorf("got status %q and elapsed %v after truncation", tc.Status(), tc.Elapsed())
+			}
+		})
+
+	}
+}
+
+func TestOutputLimitPanic(t *testing.T) {
+
+	t.Parallel()
+
+	// A passed test that recovers and logs a panic midway through 300 lines of output.
+	var input strings.Builder
+	input.WriteString(`{"Action":"run","Package":"example.com/a","Test":"TestRecover"}` + "\n")
+	for i := 1; i <= 300; i++ {
+		output := fmt.Sprintf("    a_test.go:12: line %d\n", i)
+		if i == 151 {
+			output = "    a_test.go:15: recovered: panic: boom\n"
+		}
+		fmt.Fprintf(&input, `{"Action":"output","Package":"example.com/a","Test":"TestRecover","Output":%q}`+"\n", output)
+	}
+	input.WriteString(`{"Action":"output","Package":"example.com/a","Test":"TestRecover","Output":"--- PASS: TestRecover (0.01s)\n"}` + "\n")
+	input.WriteString(`{"Action":"pass","Package":"example.com/a","Test":"TestRecover","Elapsed":0.01}` + "\n")
+	input.WriteString(`{"Action":"pass","Package":"example.com/a","Elapsed":0.02}` + "\n")
+
+	pkgs, err := Process(strings.NewReader(input.String()), WithOutputLimit(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := pkgs["example.com/a"].GetTest("TestRecover")
+	if tc == nil {
+		t.Fatal("got no test TestRecover")
+	}
+	if tc.Truncated != 200 {
+		t.Errorf("got %d truncated events, want 200", tc.Truncated)
+	}
+	if got, want := tc.Panics(), []string{"a_test.go:15: recovered: panic: boom"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got panics %q, want %q", got, want)
+	}
+}
+
+func TestUnattributedOutputLimit(t *testing.T) {
+
+	t.Parallel()
+
+	// input02.json contains 6 unattributed log lines, see TestUnattributed.
+	by, err := ioutil.ReadFile("./testdata/big/input02.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := Process(bytes.NewReader(by), WithOutputLimit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := pkgs["github.com/astromail/rover/tests"]
+	if len(pkg.Unattributed) != 2 || pkg.UnattributedTruncated != 4 {
+		t.Fatalf("got %d unattributed events (%d truncated), want 2 (4 truncated)", len(pkg.Unattributed), pkg.UnattributedTruncated)
+	}
+	if got := pkg.Unattributed[1].Output; got != "2018/10/28 00:06:54 Iterating file id: 0\n" {
+		t.Errorf("got last unattributed output %q, want second line", got)
+	}
+	// Failed tests retain all output.
+	for _, tc := range pkg.TestsByAction(ActionFail) {
+		if tc.Truncated != 0 {
+			t.Errorf("%s: got %d truncated events for failed test, want 0", tc.Name, tc.Truncated)
+		}
+	}
+}
+
+func TestTestOutput(t *testing.T) {
+
+	t.Parallel()
+
+	by, err := ioutil.ReadFile("./testdata/metrics_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The update and report lines of sort/TestCountSortOps are left out, leaving its
+	// 9 lines of t.Log output.
+	out := pkgs["sort"].GetTest("TestCountSortOps").Output()
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != 9 {
+		t.Fatalf("got %d lines of output, want 9:\n%s", len(lines), out)
+	}
+	if want := "    sort_test.go:635: Sort        100 elements:"; !strings.HasPrefix(lines[0], want) {
+		t.Errorf("got first line %q, want prefix %q", lines[0], want)
+	}
+}
@@ -1,5 +1,7 @@
 package parse
 
+import "time"
+
 // Package is the representation of a single package being tested. The
 // summary field is an event that contains all relevant information about the
 // package, namely Package (name), Elapsed and Action (big pass or fail).
@@ -19,6 +21,13 @@ type Package struct {
 	// a non-empty test name.
 	NoTestSlice Events
 
+	// Started is the time of the earliest event of the package, the start of its
+	// wall time.
+	Started time.Time
+
+	// BuildFailed indicates the package failed to build, so none of its tests ran.
+	BuildFailed bool
+
 	// Cached indicates whether the test result was obtained from the cache.
 	Cached bool
 
@@ -26,26 +35,91 @@ type Package struct {
 	Cover    bool
 	Coverage float64
 
+	// Unattributed holds output events that do not belong to any test and are not
+	// package framing lines, such as log output from init() or background goroutines.
+	Unattributed Events
+
+	// UnattributedTruncated is the number of unattributed output events discarded
+	// because of the output limit, after the first ones.
+	UnattributedTruncated int
+
+	// Ginkgo holds the spec counts of the Ginkgo suite of the package, if any.
+	Ginkgo *GinkgoSuite
+
+	// HasRace marks a package in which the race detector reported a data race. A race
+	// reported after its tests passed may leave the package summary marked as passed.
+	HasRace bool
+
 	// HasPanic marks the entire package as panicked. Game over.
 	HasPanic bool
 	// Once a package has been marked HasPanic all subsequent events are added to PanicEvents.
 	PanicEvents []*Event
+
+	// index maps test names to tests, for fast lookups on large inputs.
+	index map[string]*Test
 }
 
 // Packages is a collection of packages being tested.
 type Packages map[string]*Package
 
-// ExitCode returns 1 if at least one package is marked as panic or failed,
+// ExitCode returns 1 if at least one package is marked as panic, race or failed,
 // othewrwise return 0.
 func (p Packages) ExitCode() int {
 	for _, pkg := range p {
-		if pkg.HasPanic || pkg.Summary.Action == ActionFail {
+		if pkg.HasPanic || pkg.HasRace || pkg.Summary.Action == ActionFail {
 			return 1
 		}
 	}
 	return 0
 }
 
+// Totals holds test counts across all packages.
+type Totals struct {
+	Packages                int
+	Passed, Failed, Skipped int
+}
+
+// Totals returns the number of packages and passed, failed and skipped tests
+// (including subtests) across all packages.
+func (p Packages) Totals() Totals {
+	var t Totals
+	for _, pkg := range p {
+		t.Packages++
+		for _, test := range pkg.Tests {
+			// Packages without tests report a skip action without a test name.
+			if test.Name == "" {
+				continue
+			}
+			switch test.Status() {
+			case ActionPass:
+				t.Passed++
+			case ActionFail:
+				t.Failed++
+			case ActionSkip:
+				t.Skipped++
+			}
+		}
+	}
+	return t
+}
+
+// Coverage returns the mean coverage percentage of the packages run with -cover, as
+// statement counts are not known, and whether there are any.
+func (p Packages) Coverage() (float64, bool) {
+	var sum float64
+	var n int
+	for _, pkg := range p {
+		if pkg.Cover && !pkg.HasPanic {
+			sum += pkg.Coverage
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
 // NewPackage initializes and returns a Package.
 func NewPackage() *Package {
 	return &Package{
@@ -63,15 +137,27 @@ func (p *Package) AddEvent(event *Event) {
 			Name:    event.Test,
 			Package: event.Package,
 		}
-		p.Tests = append(p.Tests, t)
+		p.addTest(t)
 	}
 
 	t.Events = append(t.Events, event)
 }
 
+// addTest appends t to the package tests and indexes it by name.
+func (p *Package) addTest(t *Test) {
+	p.Tests = append(p.Tests, t)
+	if p.index == nil {
+		p.index = make(map[string]*Test)
+	}
+	p.index[t.Name] = t
+}
+
 // GetTest retuns a test based on given name, if no test is found
 // return nil
 func (p *Package) GetTest(name string) *Test {
+	if p.index != nil && len(p.index) == len(p.Tests) {
+		return p.index[name]
+	}
 	for _, t := range p.Tests {
 		if t.Name == name {
 			return t
@@ -95,3 +181,18 @@ func (p *Package) TestsByAction(action Action) []*Test {
 
 	return tests
 }
+
+// TopLevelTestsByAction is like TestsByAction, but excludes subtests. This mirrors
+// the counting convention of go test -v, where only top-level test functions are
+// reported in the package result.
+func (p *Package) TopLevelTestsByAction(action Action) []*Test {
+	tests := []*Test{}
+
+	for _, t := range p.TestsByAction(action) {
+		if !t.IsSubtest() {
+			tests = append(tests, t)
+		}
+	}
+
+	return tests
+}
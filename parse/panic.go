@@ -0,0 +1,37 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+)
+
+// loggedPanicRe matches output mentioning a panic anywhere in a line, such as a panic
+// recovered and logged by a test helper.
+var loggedPanicRe = regexp.MustCompile(`\bpanic: |\[recovered\]`)
+
+// Panics returns the output lines of the test that mention a panic, trimmed, such as
+// "helper.go:12: recovered: panic: boom". A panic that crashes the test binary marks
+// the package with HasPanic instead; a recovered one leaves no other trace.
+func (t *Test) Panics() []string {
+	var lines []string
+	for _, e := range t.Events {
+		if e.Action != ActionOutput {
+			continue
+		}
+		for _, line := range strings.Split(e.Output, "\n") {
+			if loggedPanicRe.MatchString(line) {
+				lines = append(lines, strings.TrimSpace(line))
+			}
+		}
+	}
+	return lines
+}
+
+// PanicTest returns the name of the test that panicked in a package marked HasPanic,
+// or "[panic]" if the panic happened outside of tests, such as in init or TestMain.
+func (p *Package) PanicTest() string {
+	if p.Summary.Test != "" {
+		return p.Summary.Test
+	}
+	return "[panic]"
+}
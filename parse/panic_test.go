@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"io/ioutil"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -86,3 +88,32 @@ func TestPanic(t *testing.T) {
 
 	}
 }
+
+func TestTestPanics(t *testing.T) {
+
+	t.Parallel()
+
+	// TestSafe recovers a panic in a helper and logs it, which go test reports as a pass.
+	input := `{"Action":"run","Package":"example.com/safe","Test":"TestSafe"}
+{"Action":"output","Package":"example.com/safe","Test":"TestSafe","Output":"=== RUN   TestSafe\n"}
+{"Action":"output","Package":"example.com/safe","Test":"TestSafe","Output":"    helper.go:12: recovered: panic: boom\n"}
+{"Action":"output","Package":"example.com/safe","Test":"TestSafe","Output":"    safe_test.go:8: no panics here\n"}
+{"Action":"output","Package":"example.com/safe","Test":"TestSafe","Output":"--- PASS: TestSafe (0.00s)\n"}
+{"Action":"pass","Package":"example.com/safe","Test":"TestSafe","Elapsed":0}
+{"Action":"pass","Package":"example.com/safe","Elapsed":0.01}
+`
+	pkgs, err := Process(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := pkgs["example.com/safe"]
+	if pkg.HasPanic {
+		t.Fatal("recovered panic must not mark the package as panicked")
+	}
+
+	got := pkg.GetTest("TestSafe").Panics()
+	want := []string{"helper.go:12: recovered: panic: boom"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,150 @@
+package parse
+
+import (
+	"sort"
+	"time"
+)
+
+// Parallelism describes how many tests of a package ran at the same time, computed
+// from the run, pause, cont and result events of tests without subtests. Parent tests
+// are left out, as they wait for their subtests.
+type Parallelism struct {
+	// Wall is the time in seconds from the first to the last test event.
+	Wall float64
+
+	// Busy is the time in seconds tests spent running, summed over tests. Time spent
+	// paused, waiting for other parallel tests, is not counted.
+	Busy float64
+
+	// Peak is the largest number of tests running at once.
+	Peak int
+
+	// Timeline holds the mean number of tests running in each of equal slices of the
+	// wall time.
+	Timeline []float64
+}
+
+// Mean returns the mean number of tests running at once.
+func (p Parallelism) Mean() float64 {
+	if p.Wall == 0 {
+		return 0
+	}
+	return p.Busy / p.Wall
+}
+
+// interval is a span of time in which a test was running.
+type interval struct {
+	start, end time.Time
+}
+
+// Parallelism returns the parallelism of the package, with the timeline split into
+// the given number of slices. Packages without timestamps, such as cached ones, have
+// zero parallelism.
+func (p *Package) Parallelism(slices int) Parallelism {
+	var par Parallelism
+
+	parents := make(map[string]bool)
+	for _, t := range p.Tests {
+		for i, r := range t.Name {
+			if r == '/' {
+				parents[t.Name[:i]] = true
+			}
+		}
+	}
+
+	var intervals []interval
+	var first, last time.Time
+	for _, t := range p.Tests {
+		if parents[t.Name] {
+			continue
+		}
+		t.SortEvents()
+
+		var start time.Time
+		for _, e := range t.Events {
+			if e.Time.IsZero() {
+				continue
+			}
+			if first.IsZero() || e.Time.Before(first) {
+				first = e.Time
+			}
+			if e.Time.After(last) {
+				last = e.Time
+			}
+
+			switch e.Action {
+			case ActionRun, ActionCont:
+				if start.IsZero() {
+					start = e.Time
+				}
+			case ActionPause, ActionPass, ActionFail, ActionSkip:
+				if !start.IsZero() {
+					if e.Time.After(start) {
+						intervals = append(intervals, interval{start, e.Time})
+					}
+					start = time.Time{}
+				}
+			}
+		}
+	}
+	if len(intervals) == 0 {
+		return par
+	}
+
+	par.Wall = last.Sub(first).Seconds()
+	for _, iv := range intervals {
+		par.Busy += iv.end.Sub(iv.start).Seconds()
+	}
+
+	// Sweep the interval boundaries in order, ending intervals before starting
+	// others at the same time.
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	edges := make([]edge, 0, 2*len(intervals))
+	for _, iv := range intervals {
+		edges = append(edges, edge{iv.start, 1}, edge{iv.end, -1})
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if !edges[i].at.Equal(edges[j].at) {
+			return edges[i].at.Before(edges[j].at)
+		}
+		return edges[i].delta < edges[j].delta
+	})
+	var running int
+	for _, e := range edges {
+		running += e.delta
+		if running > par.Peak {
+			par.Peak = running
+		}
+	}
+
+	var width time.Duration
+	if slices > 0 {
+		width = last.Sub(first) / time.Duration(slices)
+	}
+	if width > 0 {
+		par.Timeline = make([]float64, slices)
+		for i := range par.Timeline {
+			from := first.Add(time.Duration(i) * width)
+			to := from.Add(width)
+			var busy time.Duration
+			for _, iv := range intervals {
+				start, end := iv.start, iv.end
+				if start.Before(from) {
+					start = from
+				}
+				if end.After(to) {
+					end = to
+				}
+				if end.After(start) {
+					busy += end.Sub(start)
+				}
+			}
+			par.Timeline[i] = float64(busy) / float64(width)
+		}
+	}
+
+	return par
+}
@@ -0,0 +1,53 @@
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParallelism(t *testing.T) {
+
+	t.Parallel()
+
+	// input01.json runs TestSeq for 1s, then the parallel TestA and TestB for 2s and 1s,
+	// then TestParent, whose only subtest runs for 1s.
+	f, err := os.Open(filepath.Join("testdata", "parallel", "input01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	pkgs, err := Process(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := pkgs["example.com/par"].Parallelism(4)
+	want := Parallelism{
+		Wall:     4,
+		Busy:     5,
+		Peak:     2,
+		Timeline: []float64{1, 2, 1, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	if got.Mean() != 1.25 {
+		t.Errorf("got mean %v, want 1.25", got.Mean())
+	}
+}
+
+func TestParallelismNoTimestamps(t *testing.T) {
+
+	t.Parallel()
+
+	pkg := NewPackage()
+	pkg.AddEvent(&Event{Action: ActionRun, Package: "example.com/par", Test: "TestA"})
+	pkg.AddEvent(&Event{Action: ActionPass, Package: "example.com/par", Test: "TestA"})
+
+	if got := pkg.Parallelism(4); !reflect.DeepEqual(got, Parallelism{}) {
+		t.Fatalf("got %+v, want zero parallelism", got)
+	}
+}
@@ -0,0 +1,136 @@
+package parse
+
+import (
+	"bufio"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// batchSize is the number of lines decoded together by a single worker. Batching
+// amortizes the cost of channel operations over many lines.
+const batchSize = 512
+
+// batch is a contiguous run of input lines, decoded into events.
+type batch struct {
+	// seq is the position of the batch in the input, used to restore order.
+	seq int
+	// first is the 1-based line number of the first line.
+	first int
+
+	lines  [][]byte
+	events []*Event
+	errs   []error
+}
+
+func (b *batch) decode() {
+	b.events = make([]*Event, len(b.lines))
+	b.errs = make([]error, len(b.lines))
+	for i, line := range b.lines {
+		b.events[i], b.errs[i] = NewEvent(line)
+	}
+}
+
+// decode reads lines from r and decodes them into events, calling fn for each line
+// in input order. Reading, decoding and calling fn happen on separate goroutines;
+// decoding is spread over the given number of workers.
+//
+// If fn returns an error decoding stops and the error is returned at once. The reader
+// is not waited for, as a read of r may block until its writer, such as a go test
+// still running, writes again; the reader stops at its next line and r is not read
+// past it.
+func decode(r io.Reader, workers int, fn func(n int, line []byte, e *Event, err error) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	// Closed once decode returns, stopping the goroutines of all stages.
+	done := make(chan struct{})
+	defer close(done)
+
+	batches := make(chan *batch, workers)
+	decoded := make(chan *batch, workers)
+
+	// Stage 1: read lines into batches. The scanner reuses its buffer, so lines are copied.
+	var scanErr error
+	go func() {
+		defer close(batches)
+
+		b := &batch{first: 1}
+		send := func() bool {
+			select {
+			case batches <- b:
+			case <-done:
+				return false
+			}
+			b = &batch{seq: b.seq + 1, first: b.first + len(b.lines)}
+			return true
+		}
+
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			b.lines = append(b.lines, append([]byte(nil), sc.Bytes()...))
+			if len(b.lines) == batchSize && !send() {
+				return
+			}
+		}
+		if len(b.lines) > 0 && !send() {
+			return
+		}
+		scanErr = sc.Err()
+	}()
+
+	// Stage 2: decode batches concurrently.
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range batches {
+				b.decode()
+				select {
+				case decoded <- b:
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(decoded)
+	}()
+
+	// Stage 3: restore input order and aggregate.
+	pending := make(map[int]*batch)
+	var next int
+	for b := range decoded {
+		pending[b.seq] = b
+		for {
+			b, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+
+			for i := range b.lines {
+				if err := fn(b.first+i, b.lines[i], b.events[i], b.errs[i]); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// All batches have been received, so the reader has finished and scanErr is safe to read.
+	if scanErr != nil {
+		return errors.Wrap(scanErr, "bufio scanner error")
+	}
+	return nil
+}
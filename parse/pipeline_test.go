@@ -0,0 +1,130 @@
+package parse
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeOrder(t *testing.T) {
+
+	t.Parallel()
+
+	// Spread lines over several batches, with every 7th line unparseable.
+	var sb strings.Builder
+	const total = batchSize*5 + 3
+	for i := 1; i <= total; i++ {
+		if i%7 == 0 {
+			fmt.Fprintf(&sb, "not json %d\n", i)
+			continue
+		}
+		fmt.Fprintf(&sb, `{"Action":"output","Package":"p","Test":"T%d"}`+"\n", i)
+	}
+
+	var n int
+	err := decode(strings.NewReader(sb.String()), 4, func(line int, _ []byte, e *Event, err error) error {
+		n++
+		if line != n {
+			return fmt.Errorf("got line %d, want %d", line, n)
+		}
+		if line%7 == 0 {
+			if err == nil {
+				return fmt.Errorf("line %d: got nil error, want error", line)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if want := fmt.Sprintf("T%d", line); e.Test != want {
+			return fmt.Errorf("line %d: got test %q, want %q", line, e.Test, want)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != total {
+		t.Errorf("got %d lines, want %d", n, total)
+	}
+}
+
+func TestDecodeStop(t *testing.T) {
+
+	t.Parallel()
+
+	// A full batch is written, but the input is not closed, as by a go test still
+	// running: the reader then blocks in a read until the writer writes again.
+	var sb strings.Builder
+	for i := 0; i < batchSize; i++ {
+		sb.WriteString(`{"Action":"run","Package":"p","Test":"T"}` + "\n")
+	}
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go pw.Write([]byte(sb.String()))
+
+	stop := errors.New("stop")
+	errc := make(chan error, 1)
+	go func() {
+		var n int
+		errc <- decode(pr, 4, func(int, []byte, *Event, error) error {
+			n++
+			if n == 10 {
+				return stop
+			}
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-errc:
+		if err != stop {
+			t.Fatalf("got err %v, want %v", err, stop)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("decode did not return while the reader was blocked")
+	}
+}
+
+func TestProcessWorkers(t *testing.T) {
+
+	t.Parallel()
+
+	// The number of workers must not affect the result.
+	by, err := ioutil.ReadFile("./testdata/metrics_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := Process(bytes.NewReader(by), WithWorkers(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, workers := range []int{2, 8} {
+		got, err := Process(bytes.NewReader(by), WithWorkers(workers))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, pkg := range want {
+			g, ok := got[name]
+			if !ok {
+				t.Fatalf("workers=%d: got no package %q", workers, name)
+			}
+			if len(g.Tests) != len(pkg.Tests) || g.Summary.Elapsed != pkg.Summary.Elapsed {
+				t.Errorf("workers=%d: package %q differs: got %d tests (%v), want %d tests (%v)",
+					workers, name, len(g.Tests), g.Summary.Elapsed, len(pkg.Tests), pkg.Summary.Elapsed)
+			}
+			for i, tc := range pkg.Tests {
+				if g.Tests[i].Name != tc.Name || len(g.Tests[i].Events) != len(tc.Events) {
+					t.Errorf("workers=%d: test %d differs: got %q, want %q", workers, i, g.Tests[i].Name, tc.Name)
+				}
+			}
+		}
+	}
+}
@@ -24,9 +24,9 @@ func TestPrescan(t *testing.T) {
 	}{
 		{"input01.txt", "want <nil> err", nil},
 		{"input02.txt", "want failure after reading >50 lines of non-parseable events", ErrNotParseable},
-		// logic: unparseable event(s), good event(s), at least one event = fail.
-		// Once we get a good event, we expect only good events to follow until EOF.
-		{"input03.txt", "want failure when stream contains a bad event(s) -> good event(s) -> bad event", ErrNotParseable},
+		// logic: unparseable event(s), good event(s), at least one event = skipped.
+		// Once we get a good event, unparseable events are skipped until EOF.
+		{"input03.txt", "want <nil> err when stream contains a bad event(s) -> good event(s) -> bad event", nil},
 		{"input04.txt", "want failure reading <50 lines of non-parseable events", ErrNotParseable},
 	}
 
@@ -59,3 +59,36 @@ func TestPrescan(t *testing.T) {
 
 	}
 }
+
+func TestPrescanBadLines(t *testing.T) {
+
+	t.Parallel()
+
+	// input03.txt contains 12 unparseable lines: leading lines of text, a truncated
+	// event, and lines of text interleaved with events.
+	by, err := ioutil.ReadFile(filepath.Join("testdata", "prescan", "input03.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []int
+	pkgs, err := Process(bytes.NewReader(by), WithBadLineHandler(func(n int, _ []byte, _ error) {
+		lines = append(lines, n)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int{1, 2, 3, 4, 5, 7, 8, 9, 10, 11, 12, 13}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("got bad lines %v, want %v", lines, want)
+	}
+
+	pkg, ok := pkgs["github.com/mfridman/tparse/tests"]
+	if !ok {
+		t.Fatal("got no package github.com/mfridman/tparse/tests")
+	}
+	if pkg.Summary.Action != ActionPass {
+		t.Errorf("got package action %q, want %q", pkg.Summary.Action, ActionPass)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -18,120 +20,279 @@ var ErrNotParseable = errors.New("failed to parse events")
 // Returned by the Process func.
 var ErrRaceDetected = errors.New("race detected")
 
+// OptionsFunc configures the behaviour of Process.
+type OptionsFunc func(o *options)
+
+type options struct {
+	badLine     func(n int, line []byte, err error)
+	outputLimit int
+	workers     int
+	redactor    *Redactor
+	debug       *debugLog
+}
+
+// WithOutputLimit sets the number of output events retained for each passed or
+// skipped test once it completes, and for unattributed package output, to keep memory
+// bounded on very large inputs. A test retains its last events, which hold its report
+// and skip reason, along with any event mentioning a panic. Unattributed output
+// retains its first events, which hold the start of reports such as goleak's. Failed
+// and running tests always retain all output.
+//
+// By default, or with a limit <= 0, all output is retained.
+func WithOutputLimit(n int) OptionsFunc {
+	return func(o *options) {
+		o.outputLimit = n
+	}
+}
+
+// WithBadLineHandler registers fn to be called for every line that cannot be decoded
+// as an event, with the 1-based line number, the raw line and the decoding error.
+// The line slice is only valid for the duration of the call.
+func WithBadLineHandler(fn func(n int, line []byte, err error)) OptionsFunc {
+	return func(o *options) {
+		o.badLine = fn
+	}
+}
+
+// WithRedactor redacts the output of every event with r before it is processed, so
+// secrets never reach the parsed packages.
+func WithRedactor(r *Redactor) OptionsFunc {
+	return func(o *options) {
+		o.redactor = r
+	}
+}
+
+// WithWorkers sets the number of goroutines decoding JSON lines concurrently. Lines
+// are read and events aggregated on separate goroutines, in input order. The default
+// is the number of CPUs. A value < 1 is treated as 1.
+func WithWorkers(n int) OptionsFunc {
+	return func(o *options) {
+		o.workers = n
+	}
+}
+
 // Process is the entry point to the parse pkg. It consumes a reader
 // and attempts to parse go test JSON output lines until EOF.
 //
 // Note, Process will attempt to parse up to 50 lines before returning an error.
+// Once a parseable event is found, lines that cannot be decoded (such as truncated
+// or interleaved writes) are skipped. Use WithBadLineHandler to be notified of them.
 //
 // Returns PanicErr on the first package containing a test that panics.
-func Process(r io.Reader) (Packages, error) {
+//
+// If the race detector reported a data race, the packages are returned along with
+// ErrRaceDetected, and the packages it was reported in are marked HasRace.
+func Process(r io.Reader, optionsFunc ...OptionsFunc) (Packages, error) {
+	opts := options{
+		workers: runtime.NumCPU(),
+	}
+	for _, fn := range optionsFunc {
+		fn(&opts)
+	}
+
+	p := newProcessor(opts)
+	if err := decode(r, opts.workers, p.handle); err != nil {
+		return nil, err
+	}
 
-	pkgs := Packages{}
+	if !p.scan {
+		return nil, ErrNotParseable
+	}
+	if p.hasRace {
+		return p.pkgs, ErrRaceDetected
+	}
 
-	var hasRace bool
+	return p.pkgs, nil
+}
 
-	var scan bool
-	var badLines int
+// processor aggregates decoded events into packages. Events must be handled in
+// input order.
+type processor struct {
+	opts        options
+	pkgs        Packages
+	attributors map[string]*attributor
+	checkers    map[string]*checker
 
-	sc := bufio.NewScanner(r)
-	for sc.Scan() {
-		// Scan up-to 50 lines for a parseable event, if we get one, expect
-		// no errors to follow until EOF.
-		e, err := NewEvent(sc.Bytes())
-		if err != nil {
-			badLines++
-			if scan || badLines > 50 {
-				switch err.(type) {
-				case *json.SyntaxError:
-					return nil, ErrNotParseable
-				default:
-					return nil, err
-				}
-			}
-			continue
-		}
-		scan = true
+	hasRace bool
 
-		e.ProcessNestedTest()
+	// scan reports whether at least one parseable event has been handled.
+	scan     bool
+	badLines int
+}
 
-		pkg, ok := pkgs[e.Package]
-		if !ok {
-			pkg = NewPackage()
-			pkgs[e.Package] = pkg
-		}
+func newProcessor(opts options) *processor {
+	return &processor{
+		opts:        opts,
+		pkgs:        Packages{},
+		attributors: map[string]*attributor{},
+		checkers:    map[string]*checker{},
+	}
+}
 
-		if e.IsPanic() {
-			pkg.HasPanic = true
-			pkg.Summary.Action = ActionFail
-			pkg.Summary.Package = e.Package
-			pkg.Summary.Test = e.Test
+// handle processes line n, which decoded into e or failed to decode with err.
+func (p *processor) handle(n int, line []byte, e *Event, err error) error {
+	// Scan up-to 50 lines for a parseable event, if we get one, skip
+	// unparseable lines until EOF.
+	if err != nil {
+		p.opts.debug.log(n, "bad-line", nil, "error", err.Error())
+		if p.opts.badLine != nil {
+			p.opts.badLine(n, line, err)
 		}
-		// Short circuit output when panic is detected.
-		if pkg.HasPanic {
-			pkg.PanicEvents = append(pkg.PanicEvents, e)
-			continue
+		p.badLines++
+		if !p.scan && p.badLines > 50 {
+			switch err.(type) {
+			case *json.SyntaxError:
+				return ErrNotParseable
+			default:
+				return err
+			}
 		}
+		return nil
+	}
+	p.scan = true
 
-		if e.IsRace() {
-			hasRace = true
-		}
+	if p.opts.redactor != nil && e.Output != "" {
+		e.Output = p.opts.redactor.Redact(e.Output)
+	}
 
-		if e.IsCached() {
-			pkg.Cached = true
-		}
+	if !knownAction(e.Action) {
+		p.opts.debug.log(n, "unknown-action", e)
+	}
 
-		if e.NoTestFiles() {
-			pkg.NoTestFiles = true
-			// Manually mark [no test files] as "pass", because the go test tool reports the
-			// package Summary action as "skip".
-			pkg.Summary.Package = e.Package
-			pkg.Summary.Action = ActionPass
-		}
-		if e.NoTestsWarn() {
-			// One or more tests within the package contains no tests.
-			pkg.NoTestSlice = append(pkg.NoTestSlice, e)
+	c, ok := p.checkers[e.Package]
+	if !ok {
+		c = &checker{}
+		p.checkers[e.Package] = c
+	}
+	for _, ce := range c.events(e) {
+		if ce != e {
+			p.opts.debug.log(n, "gocheck", ce)
 		}
+		p.add(n, ce, c.panicked)
+	}
+	return nil
+}
 
-		if e.NoTestsToRun() {
-			// Only packages marked as "pass" will contain a summary line appended with [no tests to run].
-			// This indicates one or more tests is marked as having no tests to run.
-			pkg.NoTests = true
-			pkg.Summary.Package = e.Package
-			pkg.Summary.Action = ActionPass
-		}
+// add aggregates e, an event of line n. A panic in the output of e is recovered when
+// the panic was recovered by a gocheck suite, so it does not fail the package.
+func (p *processor) add(n int, e *Event, recovered bool) {
+	a, ok := p.attributors[e.Package]
+	if !ok {
+		a = newAttributor()
+		p.attributors[e.Package] = a
+	}
+	test := e.Test
+	a.Attribute(e)
+	if e.Test != test {
+		p.opts.debug.log(n, "attribute", e, "from", test)
+	}
 
-		if e.LastLine() {
-			pkg.Summary = e
-			continue
-		}
+	pkg, ok := p.pkgs[e.Package]
+	if !ok {
+		pkg = NewPackage()
+		p.pkgs[e.Package] = pkg
+	}
+	if !e.Time.IsZero() && (pkg.Started.IsZero() || e.Time.Before(pkg.Started)) {
+		pkg.Started = e.Time
+	}
 
-		cover, ok := e.Cover()
-		if ok {
-			pkg.Cover = true
-			pkg.Coverage = cover
-		}
+	if e.IsPanic() && !recovered {
+		p.opts.debug.log(n, "panic", e)
+		pkg.HasPanic = true
+		pkg.Summary.Action = ActionFail
+		pkg.Summary.Package = e.Package
+		pkg.Summary.Test = e.Test
+	}
+	// Short circuit output when panic is detected.
+	if pkg.HasPanic {
+		p.opts.debug.log(n, "panic-output", e)
+		pkg.PanicEvents = append(pkg.PanicEvents, e)
+		return
+	}
 
-		if e.NestedTest() {
-			pkg.Summary.Package = e.Package
-			pkg.Summary.Test = e.Test
-		}
+	if e.IsRace() {
+		p.opts.debug.log(n, "race", e)
+		p.hasRace = true
+		pkg.HasRace = true
+	}
 
-		if !e.Discard() {
-			pkg.AddEvent(e)
-		}
+	if e.IsCached() {
+		p.opts.debug.log(n, "cached", e)
+		pkg.Cached = true
 	}
 
-	if err := sc.Err(); err != nil {
-		return nil, errors.Wrap(err, "bufio scanner error")
+	if e.BuildFailed() {
+		p.opts.debug.log(n, "build-failed", e)
+		pkg.BuildFailed = true
 	}
-	if !scan {
-		return nil, ErrNotParseable
+
+	if e.NoTestFiles() {
+		p.opts.debug.log(n, "no-test-files", e)
+		pkg.NoTestFiles = true
+		// Manually mark [no test files] as "pass", because the go test tool reports the
+		// package Summary action as "skip".
+		pkg.Summary.Package = e.Package
+		pkg.Summary.Action = ActionPass
+	}
+	if e.NoTestsWarn() {
+		// One or more tests within the package contains no tests.
+		p.opts.debug.log(n, "no-tests-warning", e)
+		pkg.NoTestSlice = append(pkg.NoTestSlice, e)
+	}
+
+	if e.NoTestsToRun() {
+		// Only packages marked as "pass" will contain a summary line appended with [no tests to run].
+		// This indicates one or more tests is marked as having no tests to run.
+		p.opts.debug.log(n, "no-tests-to-run", e)
+		pkg.NoTests = true
+		pkg.Summary.Package = e.Package
+		pkg.Summary.Action = ActionPass
+	}
+
+	if e.LastLine() {
+		p.opts.debug.log(n, "summary", e)
+		pkg.Summary = e
+		return
+	}
+
+	cover, ok := e.Cover()
+	if ok {
+		p.opts.debug.log(n, "coverage", e, "coverage", strconv.FormatFloat(cover, 'f', -1, 64))
+		pkg.Cover = true
+		pkg.Coverage = cover
+	}
+
+	if pkg.ginkgoSuite(e) {
+		p.opts.debug.log(n, "ginkgo-suite", e)
+		// The counts of a converted Ginkgo report belong to no test.
+		if e.Test == "" {
+			return
+		}
+	}
+
+	if e.Unattributed() {
+		p.opts.debug.log(n, "unattributed", e)
+		if p.opts.outputLimit > 0 && len(pkg.Unattributed) >= p.opts.outputLimit {
+			pkg.UnattributedTruncated++
+			return
+		}
+		pkg.Unattributed = append(pkg.Unattributed, e)
+		return
 	}
-	if hasRace {
-		return nil, ErrRaceDetected
+
+	if e.Discard() {
+		p.opts.debug.log(n, "discard", e, "reason", discardReason(e))
+	} else {
+		pkg.AddEvent(e)
 	}
 
-	return pkgs, nil
+	// Once a test passes or is skipped its output is unlikely to be useful, so
+	// bound the output retained to keep memory constant on large inputs.
+	if p.opts.outputLimit > 0 && e.Test != "" && (e.Action == ActionPass || e.Action == ActionSkip) {
+		if t := pkg.GetTest(e.Test); t != nil {
+			t.truncateOutput(p.opts.outputLimit)
+		}
+	}
 }
 
 // ReplayOutput takes json event lines from r and returns output actions to w.
@@ -0,0 +1,166 @@
+package parse
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Quarantine is a list of known-flaky tests. Failures of quarantined tests are
+// reported separately and do not affect the exit code.
+type Quarantine []QuarantineRule
+
+// QuarantineRule matches tests by package and test name. Both patterns may
+// contain the * wildcard, which matches any sequence of characters (including "/").
+//
+// A rule matching a test also matches all of its subtests.
+type QuarantineRule struct {
+	Package string
+	Test    string
+
+	pkgRe, testRe *regexp.Regexp
+}
+
+// ReadQuarantine reads quarantine rules from r, one rule per line:
+//
+//	# comment
+//	github.com/foo/bar TestFlaky
+//	github.com/foo/* TestNetwork*
+//
+// Blank lines and lines starting with # are ignored.
+func ReadQuarantine(r io.Reader) (Quarantine, error) {
+	var q Quarantine
+
+	var n int
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		n++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("quarantine line %d: want \"<package> <test>\", got %q", n, line)
+		}
+		q = append(q, NewQuarantineRule(fields[0], fields[1]))
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, errors.Wrap(err, "bufio scanner error")
+	}
+
+	return q, nil
+}
+
+// NewQuarantineRule returns a rule matching the given package and test patterns.
+func NewQuarantineRule(pkg, test string) QuarantineRule {
+	return QuarantineRule{
+		Package: pkg,
+		Test:    test,
+		pkgRe:   globRegexp(pkg),
+		testRe:  globRegexp(test),
+	}
+}
+
+// Match reports whether the rule matches the given package and test name.
+func (r QuarantineRule) Match(pkg, test string) bool {
+	if !r.pkgRe.MatchString(pkg) {
+		return false
+	}
+	// Walk up the subtest hierarchy: TestA/b/c, TestA/b, TestA.
+	for {
+		if r.testRe.MatchString(test) {
+			return true
+		}
+		i := strings.LastIndex(test, "/")
+		if i < 0 {
+			return false
+		}
+		test = test[:i]
+	}
+}
+
+// Match reports whether any rule matches the given package and test name.
+func (q Quarantine) Match(pkg, test string) bool {
+	for _, r := range q {
+		if r.Match(pkg, test) {
+			return true
+		}
+	}
+	return false
+}
+
+// Excused reports whether the failed test t should be ignored given q. A test is
+// excused if it is quarantined, or if it has failed subtests and all of them
+// are excused; in which case the parent failed only because of its subtests.
+func (q Quarantine) Excused(pkg *Package, t *Test) bool {
+	if q.Match(t.Package, t.Name) {
+		return true
+	}
+
+	var subtests int
+	for _, sub := range pkg.TestsByAction(ActionFail) {
+		if !strings.HasPrefix(sub.Name, t.Name+"/") || strings.Contains(sub.Name[len(t.Name)+1:], "/") {
+			continue
+		}
+		subtests++
+		if !q.Excused(pkg, sub) {
+			return false
+		}
+	}
+
+	return subtests > 0
+}
+
+// QuarantinedTests returns failed tests within pkg matching q.
+func (q Quarantine) QuarantinedTests(pkg *Package) []*Test {
+	tests := []*Test{}
+
+	for _, t := range pkg.TestsByAction(ActionFail) {
+		if q.Match(t.Package, t.Name) {
+			tests = append(tests, t)
+		}
+	}
+
+	return tests
+}
+
+// ExitCode is like Packages.ExitCode, except a failed package is ignored if
+// all of its failed tests are excused by q. Panics and data races are never excused,
+// nor are failed packages without failed tests (such as build failures).
+func (q Quarantine) ExitCode(pkgs Packages) int {
+	for _, pkg := range pkgs {
+		if pkg.HasPanic || pkg.HasRace {
+			return 1
+		}
+		if pkg.Summary.Action != ActionFail {
+			continue
+		}
+
+		failed := pkg.TestsByAction(ActionFail)
+		if len(failed) == 0 {
+			return 1
+		}
+		for _, t := range failed {
+			if !q.Excused(pkg, t) {
+				return 1
+			}
+		}
+	}
+	return 0
+}
+
+// globRegexp converts a pattern containing * wildcards into an anchored regexp.
+func globRegexp(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
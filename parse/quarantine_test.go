@@ -0,0 +1,100 @@
+package parse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadQuarantine(t *testing.T) {
+
+	t.Parallel()
+
+	input := `
+# known flaky tests
+github.com/astromail/rover/tests TestCatch
+github.com/foo/*	TestNetwork*
+`
+	q, err := ReadQuarantine(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q) != 2 {
+		t.Fatalf("got %d rules, want 2", len(q))
+	}
+
+	tt := []struct {
+		pkg, test string
+		match     bool
+	}{
+		{"github.com/astromail/rover/tests", "TestCatch", true},
+		{"github.com/astromail/rover/tests", "TestCatch/catchAndRetrieve", true},
+		{"github.com/astromail/rover/tests", "TestCatchAll", false},
+		{"github.com/astromail/rover", "TestCatch", false},
+		{"github.com/foo/bar/baz", "TestNetworkDial", true},
+		{"github.com/foo/bar/baz", "TestNetworkDial/ipv6", true},
+		{"github.com/foo", "TestNetworkDial", false},
+	}
+
+	for _, test := range tt {
+		if got := q.Match(test.pkg, test.test); got != test.match {
+			t.Errorf("match %s %s: got %t, want %t", test.pkg, test.test, got, test.match)
+		}
+	}
+
+	if _, err := ReadQuarantine(strings.NewReader("github.com/foo\n")); err == nil {
+		t.Error("got nil error for malformed line, want error")
+	}
+}
+
+func TestQuarantineExitCode(t *testing.T) {
+
+	t.Parallel()
+
+	// input02.json contains a single failed subtest TestCatch/catchAndRetrieve, which
+	// also fails the parent TestCatch.
+	by, err := ioutil.ReadFile(filepath.Join("testdata", "big", "input02.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const pkg = "github.com/astromail/rover/tests"
+
+	tt := []struct {
+		name        string
+		q           Quarantine
+		exitCode    int
+		quarantined int
+	}{
+		{"empty", nil, 1, 0},
+		{"parent", Quarantine{NewQuarantineRule(pkg, "TestCatch")}, 0, 2},
+		{"subtest", Quarantine{NewQuarantineRule(pkg, "TestCatch/catchAndRetrieve")}, 0, 1},
+		{"other", Quarantine{NewQuarantineRule(pkg, "TestOther")}, 1, 0},
+	}
+
+	for _, test := range tt {
+
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.q.ExitCode(pkgs); got != test.exitCode {
+				t.Errorf("got exit code %d, want %d", got, test.exitCode)
+			}
+			if got := len(test.q.QuarantinedTests(pkgs[pkg])); got != test.quarantined {
+				t.Errorf("got %d quarantined tests, want %d", got, test.quarantined)
+			}
+		})
+
+	}
+
+	// A data race is never excused, even when all failed tests are.
+	pkgs[pkg].HasRace = true
+	if got := (Quarantine{NewQuarantineRule(pkg, "TestCatch")}).ExitCode(pkgs); got != 1 {
+		t.Errorf("got exit code %d with a data race, want 1", got)
+	}
+}
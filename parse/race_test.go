@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -60,3 +61,31 @@ func TestRaceReplay(t *testing.T) {
 
 	}
 }
+
+func TestRaceAfterPass(t *testing.T) {
+
+	t.Parallel()
+
+	// A race reported after the test body returned, with the test and package still
+	// reported as passed, must be detected all the same.
+	input := `{"Action":"run","Package":"example.com/racy","Test":"TestRacy"}
+{"Action":"output","Package":"example.com/racy","Test":"TestRacy","Output":"--- PASS: TestRacy (0.00s)\n"}
+{"Action":"pass","Package":"example.com/racy","Test":"TestRacy","Elapsed":0}
+{"Action":"output","Package":"example.com/racy","Output":"==================\n"}
+{"Action":"output","Package":"example.com/racy","Output":"WARNING: DATA RACE\n"}
+{"Action":"output","Package":"example.com/racy","Output":"PASS\n"}
+{"Action":"pass","Package":"example.com/racy","Elapsed":0.01}
+`
+	pkgs, err := Process(strings.NewReader(input))
+	if err != ErrRaceDetected {
+		t.Fatalf("got error %v; want ErrRaceDetected", err)
+	}
+	// The packages are returned along with the error, marked as racy.
+	pkg := pkgs["example.com/racy"]
+	if pkg == nil || !pkg.HasRace {
+		t.Fatalf("got package %+v, want it marked HasRace", pkg)
+	}
+	if pkgs.ExitCode() != 1 {
+		t.Errorf("got exit code %d, want 1", pkgs.ExitCode())
+	}
+}
@@ -0,0 +1,162 @@
+package parse
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultRedactPatterns match common secrets: AWS access keys, bearer and other
+// authorization credentials, GitHub and Slack tokens, JWTs, private key headers and
+// values assigned to names such as password or api_key. When a pattern has a capture
+// group, only the first group is redacted.
+var DefaultRedactPatterns = []string{
+	`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`,
+	`(?i)\baws_secret_access_key["']?\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`,
+	`(?i)\bauthorization["']?\s*[=:]\s*["']?(?:basic|bearer|token)?\s*([^\s"',]+)`,
+	`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]+=*)`,
+	`\bgh[pousr]_[A-Za-z0-9]{36,}\b`,
+	`\bgithub_pat_[A-Za-z0-9_]{22,}\b`,
+	`\bxox[abprs]-[A-Za-z0-9-]{10,}`,
+	`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----`,
+	`(?i)\b(?:password|passwd|secret|token|api[_-]?key)["']?\s*[=:]\s*["']?([^\s"',]+)`,
+}
+
+// Redacted replaces redacted text.
+const Redacted = "[REDACTED]"
+
+// Redactor removes secrets and personal paths from test output.
+type Redactor struct {
+	patterns []*regexp.Regexp
+	home     string
+}
+
+// NewRedactor returns a Redactor replacing matches of patterns with Redacted, and the
+// home directory home, if not empty, with "~".
+func NewRedactor(patterns []string, home string) (*Redactor, error) {
+	r := &Redactor{home: strings.TrimRight(home, "/\\")}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact returns s with all secrets and the home directory replaced.
+func (r *Redactor) Redact(s string) string {
+	for _, re := range r.patterns {
+		s = redactAll(re, s)
+	}
+	if r.home != "" && len(r.home) > 1 {
+		s = replacePath(s, r.home, "~")
+	}
+	return s
+}
+
+// replacePath replaces dir in s with repl where it is a whole path or the start of
+// one: not preceded by part of a path and followed by a path separator or the end of
+// the path, so that /root does not replace the start of /rootfs or /mnt/root.
+func replacePath(s, dir, repl string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(s, dir)
+		if i < 0 {
+			break
+		}
+		end := i + len(dir)
+		if (i == 0 || !isPathByte(s[i-1])) && (end == len(s) || s[end] == '/' || s[end] == '\\' || !isPathByte(s[end])) {
+			b.WriteString(s[:i])
+			b.WriteString(repl)
+		} else {
+			b.WriteString(s[:end])
+		}
+		s = s[end:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// isPathByte reports whether c may be part of a file path.
+func isPathByte(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return c == '_' || c == '-' || c == '.' || c == '/' || c == '\\' || c >= 0x80
+}
+
+// RedactStream copies the go test -json output read from rd to w, redacting the output
+// of each event. Events with nothing to redact are copied as they are, others are
+// encoded again, and lines that are not events are redacted as text.
+func (r *Redactor) RedactStream(w io.Writer, rd io.Reader) error {
+	bw := bufio.NewWriter(w)
+	br := bufio.NewReader(rd)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			if line, err = r.redactLine(strings.TrimSuffix(line, "\n")); err != nil {
+				return err
+			}
+			if _, err := bw.WriteString(line + "\n"); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return bw.Flush()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (r *Redactor) redactLine(line string) (string, error) {
+	var e struct {
+		// A pointer, as omitempty does not omit a zero time.Time.
+		Time    *time.Time `json:",omitempty"`
+		Action  Action
+		Package string  `json:",omitempty"`
+		Test    string  `json:",omitempty"`
+		Output  string  `json:",omitempty"`
+		Elapsed float64 `json:",omitempty"`
+	}
+	if err := json.Unmarshal([]byte(line), &e); err != nil {
+		return r.Redact(line), nil
+	}
+	redacted := r.Redact(e.Output)
+	if redacted == e.Output {
+		return line, nil
+	}
+	e.Output = redacted
+	by, err := json.Marshal(e)
+	return string(by), err
+}
+
+// redactAll replaces every match of re in s, or its first capture group if re has one.
+func redactAll(re *regexp.Regexp, s string) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	var last int
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(Redacted)
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// exec: "docker": executable file not found in $PATH
+	lookPathRe = regexp.MustCompile(`exec: "([^"]+)": executable file not found`)
+	// $DATABASE_URL, ${DATABASE_URL}, os.Getenv("DATABASE_URL"), DATABASE_URL=1,
+	// set DATABASE_URL to run this test, DATABASE_URL is not set. Upper case words
+	// without such context, e.g. "flaky on CI_LINUX", are not taken as variables.
+	envVarRe = regexp.MustCompile(`\$\{?([A-Z_][A-Z0-9_]*)` +
+		`|os\.(?:Getenv|LookupEnv)\("([A-Z_][A-Z0-9_]*)"\)` +
+		`|\b([A-Z][A-Z0-9_]*)=` +
+		`|\b(?i:set)\s+([A-Z][A-Z0-9_]*)\b` +
+		`|\b([A-Z][A-Z0-9_]*)\s+(?:is\s+)?(?:not set|unset|empty)\b`)
+	// docker not available, postgres is not running
+	unavailableRe = regexp.MustCompile(`(?i)\b([a-z][\w.-]*)\s+(?:is\s+)?(?:not available|unavailable|not found|not installed|not running|not reachable)`)
+	// requires a running postgres, needs docker
+	requiresRe = regexp.MustCompile(`(?i)\b(?:requires?|needs?)\s+(?:an?\s+)?(?:running\s+)?([a-z][\w.-]*)`)
+)
+
+// SkipRequirement returns the missing part of the environment that a skipped test
+// gave as its reason, by conventional skip messages: an environment variable such as
+// "$FOO_URL", named as in shell or Go code, or a tool or service such as "docker". It returns an empty string if the
+// test was not skipped for its environment.
+func (t *Test) SkipRequirement() string {
+	reason := t.SkipReason()
+	if reason == "" {
+		return ""
+	}
+	if m := lookPathRe.FindStringSubmatch(reason); m != nil {
+		return m[1]
+	}
+	if m := envVarRe.FindStringSubmatch(reason); m != nil {
+		for _, name := range m[1:] {
+			if name != "" {
+				return "$" + name
+			}
+		}
+	}
+	if m := unavailableRe.FindStringSubmatch(reason); m != nil {
+		return strings.ToLower(m[1])
+	}
+	if m := requiresRe.FindStringSubmatch(reason); m != nil {
+		return strings.ToLower(m[1])
+	}
+	return ""
+}
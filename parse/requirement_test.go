@@ -0,0 +1,51 @@
+package parse
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSkipRequirement(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		reason string
+		want   string
+	}{
+		{"set FOO_URL to run this test", "$FOO_URL"},                 // 0
+		{"skipping: $DATABASE_URL not set", "$DATABASE_URL"},         // 1
+		{"TEST_INTEGRATION=1 required", "$TEST_INTEGRATION"},         // 2
+		{"docker not available", "docker"},                           // 3
+		{"Postgres is not running", "postgres"},                      // 4
+		{`exec: "psql": executable file not found in $PATH`, "psql"}, // 5
+		{"requires a running redis", "redis"},                        // 6
+		{"skipping in short mode", ""},                               // 7
+		{"flaky on windows", ""},                                     // 8
+		{"", ""},                                                     // 9, passed
+		{`os.Getenv("AWS_REGION") is empty`, "$AWS_REGION"},          // 10
+		{"${TOKEN} required", "$TOKEN"},                              // 11
+		{"DATABASE_URL is not set", "$DATABASE_URL"},                 // 12
+		{"flaky on CI_LINUX runners", ""},                            // 13
+		{"see ISSUE_1234", ""},                                       // 14
+	}
+
+	for i, test := range tt {
+		t.Run(fmt.Sprintf("test_%d", i), func(t *testing.T) {
+			events := Events{{Action: ActionRun, Test: "TestDB"}}
+			if test.reason != "" {
+				events = append(events,
+					&Event{Action: ActionOutput, Test: "TestDB", Output: "    db_test.go:12: " + test.reason + "\n"},
+					&Event{Action: ActionOutput, Test: "TestDB", Output: "--- SKIP: TestDB (0.00s)\n"},
+					&Event{Action: ActionSkip, Test: "TestDB"},
+				)
+			} else {
+				events = append(events, &Event{Action: ActionPass, Test: "TestDB"})
+			}
+			tc := &Test{Name: "TestDB", Events: events}
+			if got := tc.SkipRequirement(); got != test.want {
+				t.Errorf("got requirement %q, want %q for %q", got, test.want, test.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package parse
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FailedTopLevel returns the names of failed top-level tests in the package,
+// sorted by name. A failed subtest always fails its parent, so rerunning the
+// top-level tests covers all failures.
+func (p *Package) FailedTopLevel() []string {
+	var names []string
+	for _, t := range p.TopLevelTestsByAction(ActionFail) {
+		names = append(names, t.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RunPattern returns a go test -run regular expression matching exactly the given
+// top-level test names.
+func RunPattern(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	return "^(" + strings.Join(quoted, "|") + ")$"
+}
+
+// PackageRunPattern returns a go test -run regular expression that matches the given
+// tests of a package, including subtests, so that they are rerun by a single command.
+// Tests whose subtests are given too are left to them. As a pattern matches each level
+// of a test name separately, each level is the union of the names given at that level:
+// TestA/x and TestB/y become "^(TestA|TestB)$/^(x|y)$", which also matches TestA/y and
+// TestB/x when they exist.
+func PackageRunPattern(names []string) string {
+	given := make(map[string]bool)
+	for _, name := range names {
+		given[name] = true
+	}
+	var leaves []string
+	for _, name := range names {
+		leaf := true
+		for other := range given {
+			if strings.HasPrefix(other, name+"/") {
+				leaf = false
+				break
+			}
+		}
+		if leaf {
+			leaves = append(leaves, name)
+		}
+	}
+	sort.Strings(leaves)
+
+	var levels [][]string
+	seen := make(map[int]map[string]bool)
+	for _, name := range leaves {
+		for i, elem := range strings.Split(name, "/") {
+			if i == len(levels) {
+				levels = append(levels, nil)
+				seen[i] = make(map[string]bool)
+			}
+			if !seen[i][elem] {
+				seen[i][elem] = true
+				levels[i] = append(levels[i], elem)
+			}
+		}
+	}
+
+	elems := make([]string, len(levels))
+	for i, level := range levels {
+		sort.Strings(level)
+		elems[i] = RunPattern(level)
+	}
+	return strings.Join(elems, "/")
+}
+
+// MergeRerun merges the results of a rerun into the package. Failed tests that pass
+// in the rerun have their events replaced with those of the rerun, and are marked
+// as flaky, along with their subtests. The events of the failed run are kept in
+// Previous. If no failed tests remain the package
+// summary is marked as pass.
+//
+// It returns the number of tests marked flaky.
+func (p *Package) MergeRerun(rerun *Package) int {
+	var flaky int
+
+	for _, name := range p.FailedTopLevel() {
+		rt := rerun.GetTest(name)
+		if rt == nil || rt.Status() != ActionPass {
+			continue
+		}
+
+		for _, r := range rerun.Tests {
+			if r.Name != name && !strings.HasPrefix(r.Name, name+"/") {
+				continue
+			}
+			t := p.GetTest(r.Name)
+			if t == nil {
+				t = &Test{Name: r.Name, Package: r.Package}
+				p.addTest(t)
+			}
+			if t.Status() == ActionFail {
+				t.Flaky = true
+				t.Previous = t.Events
+				flaky++
+			}
+			t.Events = r.Events
+		}
+	}
+
+	if p.Summary.Action == ActionFail && !p.HasPanic && flaky > 0 && len(p.TestsByAction(ActionFail)) == 0 {
+		p.Summary.Action = ActionPass
+	}
+
+	return flaky
+}
+
+// FlakyTests returns all tests marked as flaky.
+func (p *Package) FlakyTests() []*Test {
+	tests := []*Test{}
+
+	for _, t := range p.Tests {
+		if t.Flaky {
+			tests = append(tests, t)
+		}
+	}
+
+	return tests
+}
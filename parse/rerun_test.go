@@ -0,0 +1,105 @@
+package parse
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunPattern(t *testing.T) {
+
+	t.Parallel()
+
+	got := RunPattern([]string{"TestA", "TestB.c"})
+	want := `^(TestA|TestB\.c)$`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestPackageRunPattern(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		names []string
+		want  string
+	}{
+		// 0
+		{[]string{"TestB", "TestA"}, "^(TestA|TestB)$"},
+		// 1 a failed subtest fails its parent, which is left to it
+		{
+			[]string{"TestA", "TestA/x", "TestA/y", "TestB"},
+			"^(TestA|TestB)$/^(x|y)$",
+		},
+		// 2
+		{
+			[]string{"TestA", "TestA/group", "TestA/group/case_1(a)", "TestC/weird[0]"},
+			`^(TestA|TestC)$/^(group|weird\[0\])$/^(case_1\(a\))$`,
+		},
+		// 3 subtests of the same name under different parents
+		{
+			[]string{"TestA/x", "TestB/x"},
+			"^(TestA|TestB)$/^(x)$",
+		},
+		// 4
+		{nil, ""},
+	}
+
+	for i, test := range tt {
+		if got := PackageRunPattern(test.names); got != test.want {
+			t.Errorf("%d: got %q, want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestMergeRerun(t *testing.T) {
+
+	t.Parallel()
+
+	// input02.json fails TestCatch (via its subtest), which passes in the rerun.
+	process := func(name string) Packages {
+		by, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkgs, err := Process(bytes.NewReader(by))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pkgs
+	}
+
+	const name = "github.com/astromail/rover/tests"
+
+	pkgs := process(filepath.Join("testdata", "big", "input02.json"))
+	rerun := process(filepath.Join("testdata", "rerun", "input01.json"))
+
+	pkg := pkgs[name]
+	if got := pkg.FailedTopLevel(); len(got) != 1 || got[0] != "TestCatch" {
+		t.Fatalf("got failed top-level tests %v, want [TestCatch]", got)
+	}
+
+	if n := pkg.MergeRerun(rerun[name]); n != 2 {
+		t.Errorf("got %d flaky tests, want 2", n)
+	}
+	if got := len(pkg.TestsByAction(ActionFail)); got != 0 {
+		t.Errorf("got %d failed tests after merge, want 0", got)
+	}
+	if got := len(pkg.FlakyTests()); got != 2 {
+		t.Errorf("got %d flaky tests after merge, want 2", got)
+	}
+	for _, test := range pkg.FlakyTests() {
+		previous := Test{Name: test.Name, Events: test.Previous}
+		if previous.Status() != ActionFail {
+			t.Errorf("got previous status %q for %s, want %q", previous.Status(), test.Name, ActionFail)
+		}
+	}
+	if pkg.Summary.Action != ActionPass {
+		t.Errorf("got package action %q, want %q", pkg.Summary.Action, ActionPass)
+	}
+	if code := pkgs.ExitCode(); code != 0 {
+		t.Errorf("got exit code %d, want 0", code)
+	}
+}
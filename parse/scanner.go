@@ -0,0 +1,169 @@
+package parse
+
+import (
+	"bufio"
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Scanner parses go test JSON output one event at a time, for long-lived consumers
+// such as daemons and editor integrations that act on events as they arrive. Unlike
+// Process, which batches lines, each line is handled as soon as it is read.
+//
+// Successive calls to Scan step through the events, skipping lines that cannot be
+// decoded, as Process does. Scanning stops at the end of the input, on the first
+// error, or when the context is done.
+type Scanner struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	p      *processor
+	lines  chan scannedLine
+
+	// readErr is set by the reading goroutine before it closes lines.
+	readErr error
+
+	event *Event
+	test  *Test
+	err   error
+	done  bool
+}
+
+// scannedLine is line n of the input, decoded into e or failed to decode with err.
+type scannedLine struct {
+	n    int
+	line []byte
+	e    *Event
+	err  error
+}
+
+// NewScanner returns a scanner reading go test JSON output from r. The options are
+// those of Process; WithWorkers has no effect.
+//
+// Cancel ctx to stop scanning early; Scan then returns false and Err returns the
+// context error. A read from r blocked when ctx is done is not interrupted, so close r
+// as well if it may block indefinitely.
+func NewScanner(ctx context.Context, r io.Reader, optionsFunc ...OptionsFunc) *Scanner {
+	var opts options
+	for _, fn := range optionsFunc {
+		fn(&opts)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Scanner{
+		ctx:    ctx,
+		cancel: cancel,
+		p:      newProcessor(opts),
+		lines:  make(chan scannedLine),
+	}
+	go s.read(r)
+	return s
+}
+
+// read decodes the lines of r and sends them to Scan until the end of the input or
+// until the scanner is done.
+func (s *Scanner) read(r io.Reader) {
+	defer close(s.lines)
+
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := append([]byte(nil), sc.Bytes()...)
+		e, err := NewEvent(line)
+		select {
+		case s.lines <- scannedLine{n, line, e, err}:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+	if err := sc.Err(); err != nil {
+		s.readErr = errors.Wrap(err, "bufio scanner error")
+	}
+}
+
+// Scan advances the scanner to the next event, which is then available through Event.
+// It returns false when scanning stops; Err then reports why, or returns nil at the
+// end of the input.
+func (s *Scanner) Scan() bool {
+	s.event, s.test = nil, nil
+	if s.done {
+		return false
+	}
+	for {
+		if err := s.ctx.Err(); err != nil {
+			return s.stop(err)
+		}
+		var l scannedLine
+		var ok bool
+		select {
+		case l, ok = <-s.lines:
+		case <-s.ctx.Done():
+			return s.stop(s.ctx.Err())
+		}
+		if !ok {
+			return s.stop(s.finish())
+		}
+
+		if err := s.p.handle(l.n, l.line, l.e, l.err); err != nil {
+			return s.stop(err)
+		}
+		if l.err != nil {
+			continue
+		}
+
+		s.event = l.e
+		if l.e.Test != "" && (l.e.Action == ActionPass || l.e.Action == ActionFail || l.e.Action == ActionSkip) {
+			if pkg, ok := s.p.pkgs[l.e.Package]; ok {
+				s.test = pkg.GetTest(l.e.Test)
+			}
+		}
+		return true
+	}
+}
+
+// finish returns the error at the end of the input, if any, as Process would.
+func (s *Scanner) finish() error {
+	switch {
+	case s.readErr != nil:
+		return s.readErr
+	case !s.p.scan:
+		return ErrNotParseable
+	case s.p.hasRace:
+		return ErrRaceDetected
+	}
+	return nil
+}
+
+// stop ends scanning with err, which may be nil, and returns false.
+func (s *Scanner) stop(err error) bool {
+	s.done = true
+	s.err = err
+	s.cancel()
+	return false
+}
+
+// Event returns the event read by the last call to Scan, with its output redacted if
+// WithRedactor is set.
+func (s *Scanner) Event() *Event {
+	return s.event
+}
+
+// Test returns the test completed by the event read by the last call to Scan, that is
+// one that passed, failed or was skipped, or nil. Tests of panicked packages are not
+// recorded, so never returned.
+func (s *Scanner) Test() *Test {
+	return s.test
+}
+
+// Packages returns the packages parsed so far. They are updated by each call to Scan,
+// so must not be used concurrently with it.
+func (s *Scanner) Packages() Packages {
+	return s.p.pkgs
+}
+
+// Err returns the error that stopped scanning, or nil if the end of the input was
+// reached. A done context returns its error, context.Canceled or
+// context.DeadlineExceeded.
+func (s *Scanner) Err() error {
+	return s.err
+}
@@ -0,0 +1,116 @@
+package parse
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScanner(t *testing.T) {
+
+	t.Parallel()
+
+	f, err := os.Open(filepath.Join("testdata", "parallel", "input01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s := NewScanner(context.Background(), f)
+	var events int
+	var tests []string
+	for s.Scan() {
+		events++
+		if test := s.Test(); test != nil {
+			tests = append(tests, test.Name)
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if events != 16 {
+		t.Errorf("got %d events, want 16", events)
+	}
+	want := []string{"TestSeq", "TestB", "TestA", "TestParent/child", "TestParent"}
+	if !reflect.DeepEqual(tests, want) {
+		t.Errorf("got completed tests %v, want %v", tests, want)
+	}
+	pkg, ok := s.Packages()["example.com/par"]
+	if !ok || pkg.Summary.Action != ActionPass {
+		t.Fatalf("got packages %v, want example.com/par passed", s.Packages())
+	}
+
+	// Scanning is over.
+	if s.Scan() {
+		t.Error("got Scan true after the end of the input")
+	}
+}
+
+func TestScannerErrors(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		input string
+		want  error
+	}{
+		// 0: bad lines are skipped.
+		{"not json\n" + `{"Action":"pass","Package":"p"}` + "\n", nil},
+		// 1
+		{strings.Repeat("not json\n", 51), ErrNotParseable},
+		// 2
+		{`{"Action":"output","Package":"p","Test":"TestRace","Output":"WARNING: DATA RACE\n"}` + "\n", ErrRaceDetected},
+	}
+
+	for i, test := range tt {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			s := NewScanner(context.Background(), strings.NewReader(test.input))
+			for s.Scan() {
+			}
+			if err := s.Err(); err != test.want {
+				t.Errorf("got error %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestScannerCancel(t *testing.T) {
+
+	t.Parallel()
+
+	// The writer never closes, as a live go test process would not.
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go fmt.Fprintln(pw, `{"Action":"run","Package":"p","Test":"TestLong"}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewScanner(ctx, pr)
+	if !s.Scan() || s.Event().Test != "TestLong" {
+		t.Fatalf("got event %v, want the run event of TestLong", s.Event())
+	}
+
+	cancel()
+	if s.Scan() {
+		t.Fatal("got Scan true after cancel")
+	}
+	if err := s.Err(); err != context.Canceled {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+
+	// A deadline stops a scan blocked waiting for input.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	s = NewScanner(ctx, pr)
+	if s.Scan() {
+		t.Fatal("got Scan true without input")
+	}
+	if err := s.Err(); err != context.DeadlineExceeded {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+}
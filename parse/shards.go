@@ -0,0 +1,69 @@
+package parse
+
+// MergeShards merges the packages parsed from the outputs of several shards of one
+// run, such as distributed runners each running part of the tests, into the packages
+// of the whole run. A package tested by several shards fails if it failed in any of
+// them, and its tests are combined. Coverage, which cannot be combined, is the highest
+// of the shards.
+//
+// The packages of the shards are reused and modified.
+func MergeShards(shards []Packages) Packages {
+	merged := make(Packages)
+	for _, pkgs := range shards {
+		for name, pkg := range pkgs {
+			m, ok := merged[name]
+			if !ok {
+				merged[name] = pkg
+				continue
+			}
+			m.mergeShard(pkg)
+		}
+	}
+	return merged
+}
+
+// mergeShard merges the results of another shard for the same package into p.
+func (p *Package) mergeShard(other *Package) {
+	for _, t := range other.Tests {
+		if existing := p.GetTest(t.Name); existing != nil {
+			existing.Events = append(existing.Events, t.Events...)
+			continue
+		}
+		p.addTest(t)
+	}
+
+	if p.Summary.Action == "" || other.Summary.Action == ActionFail {
+		p.Summary.Action = other.Summary.Action
+		p.Summary.Test = other.Summary.Test
+	}
+	p.Summary.Package = other.Summary.Package
+	if other.Summary.Elapsed > p.Summary.Elapsed {
+		p.Summary.Elapsed = other.Summary.Elapsed
+	}
+	if other.Summary.Time.After(p.Summary.Time) {
+		p.Summary.Time = other.Summary.Time
+	}
+	if !other.Started.IsZero() && (p.Started.IsZero() || other.Started.Before(p.Started)) {
+		p.Started = other.Started
+	}
+
+	if p.Ginkgo == nil {
+		p.Ginkgo = other.Ginkgo
+	}
+	p.NoTestFiles = p.NoTestFiles && other.NoTestFiles
+	p.NoTests = p.NoTests || other.NoTests
+	p.NoTestSlice = append(p.NoTestSlice, other.NoTestSlice...)
+	p.BuildFailed = p.BuildFailed || other.BuildFailed
+	p.Cached = p.Cached && other.Cached
+	if other.Cover {
+		p.Cover = true
+		if other.Coverage > p.Coverage {
+			p.Coverage = other.Coverage
+		}
+	}
+	p.Unattributed = append(p.Unattributed, other.Unattributed...)
+	p.UnattributedTruncated += other.UnattributedTruncated
+	p.HasRace = p.HasRace || other.HasRace
+	p.HasPanic = p.HasPanic || other.HasPanic
+	p.PanicEvents = append(p.PanicEvents, other.PanicEvents...)
+}
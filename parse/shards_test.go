@@ -0,0 +1,69 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeShards(t *testing.T) {
+
+	t.Parallel()
+
+	inputs := []string{
+		// shard 0
+		`{"Action":"pass","Package":"example.com/a","Test":"TestA1","Elapsed":1}
+{"Action":"output","Package":"example.com/a","Output":"coverage: 40.0% of statements\n"}
+{"Action":"pass","Package":"example.com/a","Elapsed":1.5}
+{"Action":"pass","Package":"example.com/b","Test":"TestB"}
+{"Action":"pass","Package":"example.com/b","Elapsed":0.5}
+`,
+		// shard 1
+		`{"Action":"fail","Package":"example.com/a","Test":"TestA2","Elapsed":2}
+{"Action":"output","Package":"example.com/a","Output":"coverage: 30.0% of statements\n"}
+{"Action":"fail","Package":"example.com/a","Elapsed":2.5}
+`,
+		// shard 2: a later pass does not hide the failure.
+		`{"Action":"pass","Package":"example.com/a","Test":"TestA3"}
+{"Action":"pass","Package":"example.com/a","Elapsed":0.1}
+`,
+	}
+
+	var shards []Packages
+	for _, input := range inputs {
+		pkgs, err := Process(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		shards = append(shards, pkgs)
+	}
+
+	pkgs := MergeShards(shards)
+	if len(pkgs) != 2 {
+		t.Fatalf("got %d packages, want 2", len(pkgs))
+	}
+
+	a := pkgs["example.com/a"]
+	if a.Summary.Action != ActionFail {
+		t.Errorf("got action %q, want fail", a.Summary.Action)
+	}
+	if a.Summary.Elapsed != 2.5 {
+		t.Errorf("got elapsed %v, want 2.5", a.Summary.Elapsed)
+	}
+	if a.Coverage != 40 {
+		t.Errorf("got coverage %v, want 40", a.Coverage)
+	}
+	for name, want := range map[string]Action{"TestA1": ActionPass, "TestA2": ActionFail, "TestA3": ActionPass} {
+		test := a.GetTest(name)
+		if test == nil {
+			t.Errorf("missing test %s", name)
+			continue
+		}
+		if got := test.Status(); got != want {
+			t.Errorf("%s: got status %q, want %q", name, got, want)
+		}
+	}
+
+	if got := pkgs.ExitCode(); got != 1 {
+		t.Errorf("got exit code %d, want 1", got)
+	}
+}
@@ -0,0 +1,94 @@
+package parse
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Stats summarizes the elapsed time (in seconds) of tests within a single package.
+type Stats struct {
+	// Count is the number of tests, including subtests.
+	Count int
+
+	// Total is the cumulative elapsed time of all top-level tests. Subtests are
+	// excluded because their elapsed time is already accounted for by the parent.
+	Total float64
+
+	// Duration percentiles computed over all tests, including subtests.
+	P50, P90, P99 float64
+}
+
+// Stats returns duration statistics computed from the elapsed time of each test.
+func (p *Package) Stats() Stats {
+	var s Stats
+
+	durations := make([]float64, 0, len(p.Tests))
+	for _, t := range p.Tests {
+		elapsed := t.Elapsed()
+		durations = append(durations, elapsed)
+		if !t.IsSubtest() {
+			s.Total += elapsed
+		}
+	}
+	sort.Float64s(durations)
+
+	s.Count = len(durations)
+	s.P50 = percentile(durations, 50)
+	s.P90 = percentile(durations, 90)
+	s.P99 = percentile(durations, 99)
+
+	return s
+}
+
+// Wall returns the wall time of the package in seconds, from its earliest event to
+// its final pass or fail event. When events lack timestamps, the elapsed time reported
+// by go test is returned instead.
+func (p *Package) Wall() float64 {
+	if p.Started.IsZero() || p.Summary.Time.IsZero() || p.Summary.Time.Before(p.Started) {
+		return p.Summary.Elapsed
+	}
+	return p.Summary.Time.Sub(p.Started).Seconds()
+}
+
+// Wall returns the wall-clock time in seconds of the whole run, from the first event
+// of any package to the last. Packages without timestamps count for at least their
+// own wall time.
+func (p Packages) Wall() float64 {
+	var first, last time.Time
+	var wall float64
+	for _, pkg := range p {
+		if pkg.Summary == nil {
+			continue
+		}
+		if w := pkg.Wall(); w > wall {
+			wall = w
+		}
+		if pkg.Started.IsZero() || pkg.Summary.Time.IsZero() {
+			continue
+		}
+		if first.IsZero() || pkg.Started.Before(first) {
+			first = pkg.Started
+		}
+		if pkg.Summary.Time.After(last) {
+			last = pkg.Summary.Time
+		}
+	}
+	if w := last.Sub(first).Seconds(); w > wall {
+		wall = w
+	}
+	return wall
+}
+
+// percentile returns the nearest-rank percentile p of sorted. Returns zero if
+// sorted is empty.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
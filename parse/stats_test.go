@@ -0,0 +1,147 @@
+package parse
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+
+	t.Parallel()
+
+	// This test depends on elapsed_test.json, which contains the output of 2 std lib tests
+	// with known elapsed time: TestCompareStrings (3.49s) and TestCaseConsistency (0.17s).
+
+	by, err := ioutil.ReadFile("./testdata/elapsed_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs, err := Process(bytes.NewReader(by))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkg, ok := pkgs["strings"]
+	if !ok {
+		t.Fatal("got no strings package, want strings")
+	}
+
+	s := pkg.Stats()
+	if s.Count != 2 {
+		t.Errorf("got count %d, want 2", s.Count)
+	}
+	if math.Abs(s.Total-3.66) > 1e-9 {
+		t.Errorf("got total %v, want 3.66", s.Total)
+	}
+	if s.P50 != 0.17 {
+		t.Errorf("got p50 %v, want 0.17", s.P50)
+	}
+	if s.P90 != 3.49 || s.P99 != 3.49 {
+		t.Errorf("got p90 %v and p99 %v, want 3.49", s.P90, s.P99)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+
+	t.Parallel()
+
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	tt := []struct {
+		input []float64
+		p     float64
+		want  float64
+	}{
+		{nil, 50, 0},
+		{[]float64{4}, 99, 4},
+		{sorted, 50, 5},
+		{sorted, 90, 9},
+		{sorted, 99, 10},
+		{sorted, 0, 1},
+	}
+
+	for i, test := range tt {
+
+		t.Run(fmt.Sprintf("percentile_%d", i), func(t *testing.T) {
+			got := percentile(test.input, test.p)
+			if got != test.want {
+				t.Errorf("got p%v %v, want %v", test.p, got, test.want)
+			}
+		})
+
+	}
+}
+
+func TestWall(t *testing.T) {
+
+	t.Parallel()
+
+	// input01.json has events from 10:00:00 to 10:00:04, while go test reports 4.005s.
+	f, err := os.Open(filepath.Join("testdata", "parallel", "input01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	pkgs, err := Process(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pkgs["example.com/par"].Wall(); got != 4 {
+		t.Errorf("got wall %v, want 4", got)
+	}
+
+	// Without timestamps, the elapsed time reported by go test is used.
+	pkg := NewPackage()
+	pkg.Summary = &Event{Action: ActionPass, Package: "example.com/par", Elapsed: 1.5}
+	if got := pkg.Wall(); got != 1.5 {
+		t.Errorf("got wall %v, want 1.5", got)
+	}
+}
+
+func TestPackagesWall(t *testing.T) {
+
+	t.Parallel()
+
+	start := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	newPackage := func(name string, from, to time.Duration, elapsed float64) *Package {
+		pkg := NewPackage()
+		pkg.Summary = &Event{Action: ActionPass, Package: name, Elapsed: elapsed}
+		if to > 0 {
+			pkg.Started = start.Add(from)
+			pkg.Summary.Time = start.Add(to)
+		}
+		return pkg
+	}
+
+	tt := []struct {
+		pkgs Packages
+		want float64
+	}{
+		// 0: overlapping packages span from the first start to the last end.
+		{Packages{
+			"a": newPackage("a", 0, 3*time.Second, 3),
+			"b": newPackage("b", time.Second, 5*time.Second, 4),
+		}, 5},
+		// 1: a package without timestamps counts for its own elapsed time.
+		{Packages{
+			"a": newPackage("a", 0, 2*time.Second, 2),
+			"b": newPackage("b", 0, 0, 7.5),
+		}, 7.5},
+		// 2
+		{Packages{}, 0},
+	}
+
+	for i, test := range tt {
+		if got := test.pkgs.Wall(); got != test.want {
+			t.Errorf("%d: got wall %v, want %v", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package parse
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Suite is a testify suite, run by suite.Run from a Go test function such as
+// TestUserSuite. Each test method of the suite runs as a subtest named after it, e.g.
+// TestUserSuite/TestCreate.
+type Suite struct {
+	// Test is the Go test function running the suite.
+	Test *Test
+	// Methods are the subtests running the test methods of the suite, by name.
+	Methods []*Test
+}
+
+// Suites returns the testify suites of the package, by name. A test is taken to run a
+// suite if all of its subtests are named as test methods, which testify requires to
+// begin with "Test".
+func (p *Package) Suites() []*Suite {
+	suites := make(map[string]*Suite)
+	notSuite := make(map[string]bool)
+	for _, t := range p.Tests {
+		parts := strings.Split(t.Name, "/")
+		if len(parts) != 2 {
+			continue
+		}
+		if !isMethodName(parts[1]) {
+			notSuite[parts[0]] = true
+			continue
+		}
+		s, ok := suites[parts[0]]
+		if !ok {
+			parent := p.GetTest(parts[0])
+			if parent == nil {
+				continue
+			}
+			s = &Suite{Test: parent}
+			suites[parts[0]] = s
+		}
+		s.Methods = append(s.Methods, t)
+	}
+
+	var out []*Suite
+	for name, s := range suites {
+		if notSuite[name] {
+			continue
+		}
+		sort.Slice(s.Methods, func(i, j int) bool {
+			return s.Methods[i].Name < s.Methods[j].Name
+		})
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Test.Name < out[j].Test.Name
+	})
+	return out
+}
+
+// isMethodName reports whether name is that of an exported Go method beginning with
+// "Test", as the test methods of a testify suite are.
+func isMethodName(name string) bool {
+	if !strings.HasPrefix(name, "Test") {
+		return false
+	}
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// FixtureFailure is a failure repeated by methods of a suite, at the same location and
+// with the same message, as a failing SetupTest, BeforeTest, TearDownTest or AfterTest
+// repeats for every method it runs for.
+type FixtureFailure struct {
+	Location Location
+	Message  string
+	// Methods are the failed methods, by name.
+	Methods []*Test
+}
+
+// fixtureRe matches the output of failures in the fixture methods run around each test
+// method: a stack naming one of them, or an error trace through testify's suite package,
+// which calls them. The error trace of a failure in a test method ends at the method.
+var fixtureRe = regexp.MustCompile(`\b(?:SetupTest|BeforeTest|TearDownTest|AfterTest)\b|/testify(?:@[^/]+)?/suite/suite\.go:`)
+
+// FixtureFailures returns the failures shared by two or more failed methods of the
+// suite, in order of their first method. Only failures in fixture methods are shared;
+// methods that fail alike in their own code, such as at the same helper, are not.
+func (s *Suite) FixtureFailures() []FixtureFailure {
+	var failures []FixtureFailure
+	index := make(map[string]int)
+	for _, t := range s.Methods {
+		if t.Status() != ActionFail || !fixtureRe.MatchString(t.Output()) {
+			continue
+		}
+		loc, ok := t.Location()
+		if !ok {
+			continue
+		}
+		msg := t.Message()
+		key := loc.String() + "\x00" + msg
+		i, ok := index[key]
+		if !ok {
+			i = len(failures)
+			index[key] = i
+			failures = append(failures, FixtureFailure{Location: loc, Message: msg})
+		}
+		failures[i].Methods = append(failures[i].Methods, t)
+	}
+
+	shared := failures[:0]
+	for _, f := range failures {
+		if len(f.Methods) > 1 {
+			shared = append(shared, f)
+		}
+	}
+	return shared
+}
@@ -0,0 +1,129 @@
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSuites(t *testing.T) {
+
+	t.Parallel()
+
+	// input01.json contains a testify suite whose SetupTest failed for each of its three
+	// methods, a suite with a passed and a failed method, a suite whose two methods
+	// failed at the same helper, and a table-driven test.
+	f, err := os.Open(filepath.Join("testdata", "suite", "input01.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	pkgs, err := Process(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := pkgs["example.com/users"]
+	if pkg == nil {
+		t.Fatal("missing package")
+	}
+
+	suites := pkg.Suites()
+	tt := []struct {
+		name     string
+		methods  int
+		failures []int
+	}{
+		// 0
+		{"TestCartSuite", 2, nil},
+		// 1
+		{"TestOrderSuite", 2, nil},
+		// 2
+		{"TestUserSuite", 3, []int{3}},
+	}
+	if len(suites) != len(tt) {
+		t.Fatalf("got %d suites, want %d", len(suites), len(tt))
+	}
+
+	for i, test := range tt {
+		s := suites[i]
+		if s.Test.Name != test.name {
+			t.Errorf("%d: got suite %s, want %s", i, s.Test.Name, test.name)
+		}
+		if len(s.Methods) != test.methods {
+			t.Errorf("%d: got %d methods, want %d", i, len(s.Methods), test.methods)
+		}
+		failures := s.FixtureFailures()
+		if len(failures) != len(test.failures) {
+			t.Fatalf("%d: got %d fixture failures, want %d", i, len(failures), len(test.failures))
+		}
+		for j, f := range failures {
+			if len(f.Methods) != test.failures[j] {
+				t.Errorf("%d: failure %d: got %d methods, want %d", i, j, len(f.Methods), test.failures[j])
+			}
+		}
+	}
+
+	f0 := suites[2].FixtureFailures()[0]
+	if got, want := f0.Location.String(), "suite_test.go:21"; got != want {
+		t.Errorf("got location %s, want %s", got, want)
+	}
+	if got, want := f0.Message, "Received unexpected error: dial tcp 127.0.0.1:5432: connect: connection refused"; got != want {
+		t.Errorf("got message %q, want %q", got, want)
+	}
+	if got, want := f0.Methods[0].Name, "TestUserSuite/TestCreate"; got != want {
+		t.Errorf("got first method %s, want %s", got, want)
+	}
+}
+
+func TestIsMethodName(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		name string
+		want bool
+	}{
+		// 0
+		{"TestCreate", true},
+		// 1
+		{"Test_create_2", true},
+		// 2
+		{"empty_input", false},
+		// 3
+		{"TestCreate#01", false},
+		// 4
+		{"Testing(a)", false},
+	}
+
+	for i, test := range tt {
+		if got := isMethodName(test.name); got != test.want {
+			t.Errorf("%d: isMethodName(%q) = %v, want %v", i, test.name, got, test.want)
+		}
+	}
+}
+
+func TestFixtureRe(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		output string
+		want   bool
+	}{
+		// 0, an assertion in SetupTest
+		{"        \tError Trace:\t/src/users/suite_test.go:21\n        \t            \t\t\t\t/go/pkg/mod/github.com/stretchr/testify@v1.8.4/suite/suite.go:187\n", true},
+		// 1, a panic in TearDownTest
+		{"    suite.go:87: test panicked: boom\n        example.com/users.(*UserSuite).TearDownTest(0xc000010000)\n", true},
+		// 2, an assertion in a test method
+		{"        \tError Trace:\t/src/users/order_test.go:15\n        \tError:      \tShould be true\n", false},
+		// 3
+		{"    users_test.go:12: TestSetupTestdata failed\n", false},
+	}
+
+	for i, test := range tt {
+		if got := fixtureRe.MatchString(test.output); got != test.want {
+			t.Errorf("%d: got %v, want %v", i, got, test.want)
+		}
+	}
+}
@@ -10,6 +10,52 @@ type Test struct {
 	Name    string
 	Package string
 	Events
+
+	// Flaky indicates the test failed, but passed when it was run again.
+	Flaky bool
+
+	// Previous holds the events of the failed run of a flaky test, which were
+	// replaced by those of the passing rerun.
+	Previous Events
+
+	// Truncated is the number of output events discarded because of the output
+	// limit, oldest first. Only tests that did not fail are truncated.
+	Truncated int
+}
+
+// truncateOutput discards all but the last limit output events. Events of other
+// actions are always retained, as they determine the status and elapsed time, and so
+// are output events mentioning a panic, which -fail-on-panic looks for.
+func (t *Test) truncateOutput(limit int) {
+	var outputs int
+	for _, e := range t.Events {
+		if e.Action == ActionOutput {
+			outputs++
+		}
+	}
+	if outputs <= limit {
+		return
+	}
+
+	drop := outputs - limit
+	events := make(Events, 0, len(t.Events)-drop)
+	for _, e := range t.Events {
+		if e.Action == ActionOutput && drop > 0 {
+			drop--
+			if !loggedPanicRe.MatchString(e.Output) {
+				t.Truncated++
+				continue
+			}
+		}
+		events = append(events, e)
+	}
+	t.Events = events
+}
+
+// IsSubtest reports whether the test is a subtest, i.e., the test name contains
+// a slash: TestParent/subtest
+func (t *Test) IsSubtest() bool {
+	return strings.Contains(t.Name, "/")
 }
 
 // Elapsed indicates how long a given test ran (in seconds), by scanning for the largest
@@ -47,6 +93,24 @@ func (t *Test) Status() Action {
 	return ActionFail
 }
 
+// Output returns the output of the test, such as t.Log and fmt.Println output, without
+// the "=== RUN" update lines and "--- PASS" report lines added by go test.
+func (t *Test) Output() string {
+	t.SortEvents()
+
+	var out strings.Builder
+	for _, e := range t.Events {
+		if e.Action != ActionOutput || strings.HasPrefix(e.Output, "=== ") {
+			continue
+		}
+		if _, ok := reportName(e.Output); ok {
+			continue
+		}
+		out.WriteString(e.Output)
+	}
+	return out.String()
+}
+
 // Stack returns debugging information from output events for failed or skipped tests.
 func (t *Test) Stack() string {
 
@@ -81,8 +145,9 @@ func (t *Test) Stack() string {
 }
 
 // SortEvents sorts test events by elapsed time in ascending order, i.e., oldest to newest.
+// Events with the same time keep their order.
 func (t *Test) SortEvents() {
-	sort.Slice(t.Events, func(i, j int) bool {
+	sort.SliceStable(t.Events, func(i, j int) bool {
 		return t.Events[i].Time.Before(t.Events[j].Time)
 	})
 }
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/mfridman/tparse/parse"
+)
+
+// progress tracks a running go test invocation and periodically renders a single
+// status line: packages completed out of the total, running tests and elapsed time.
+//
+// progress is an io.Writer, meant to receive a copy of the go test -json stream.
+type progress struct {
+	out   io.Writer
+	start time.Time
+
+	mu       sync.Mutex
+	partial  []byte
+	total    int // zero if unknown
+	seen     map[string]bool
+	complete map[string]bool
+	running  map[string]bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// newProgress starts rendering progress to out every interval, until Stop is called.
+// count returns the total number of packages, for example by looking them up with
+// countPackages. If it fails, the total is the number of packages seen.
+func newProgress(out io.Writer, count func() (int, error), interval time.Duration) *progress {
+	p := &progress{
+		out:      out,
+		start:    time.Now(),
+		seen:     make(map[string]bool),
+		complete: make(map[string]bool),
+		running:  make(map[string]bool),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go func() {
+		if n, err := count(); err == nil {
+			p.mu.Lock()
+			p.total = n
+			p.mu.Unlock()
+		}
+	}()
+
+	go func() {
+		defer close(p.done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				p.render()
+			case <-p.stop:
+				// Clear the status line.
+				fmt.Fprint(p.out, "\r\x1b[K")
+				return
+			}
+		}
+	}()
+
+	return p
+}
+
+// Write consumes go test -json output, updating progress for each complete line.
+func (p *progress) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.partial = append(p.partial, b...)
+	for {
+		i := bytes.IndexByte(p.partial, '\n')
+		if i < 0 {
+			break
+		}
+		if e, err := parse.NewEvent(p.partial[:i]); err == nil {
+			p.update(e)
+		}
+		p.partial = p.partial[i+1:]
+	}
+	return len(b), nil
+}
+
+func (p *progress) update(e *parse.Event) {
+	if e.Package == "" {
+		return
+	}
+	p.seen[e.Package] = true
+
+	key := e.Package + " " + e.Test
+	switch e.Action {
+	case parse.ActionRun, parse.ActionCont:
+		p.running[key] = true
+	case parse.ActionPause:
+		delete(p.running, key)
+	case parse.ActionPass, parse.ActionFail, parse.ActionSkip:
+		if e.Test == "" {
+			p.complete[e.Package] = true
+			return
+		}
+		delete(p.running, key)
+	}
+}
+
+// String returns the current status line.
+func (p *progress) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	total := p.total
+	if total < len(p.seen) {
+		total = len(p.seen)
+	}
+	elapsed := time.Since(p.start).Round(time.Second)
+
+	return fmt.Sprintf("tparse: %d/%d packages, %d running tests, %s elapsed",
+		len(p.complete), total, len(p.running), elapsed)
+}
+
+func (p *progress) render() {
+	fmt.Fprintf(p.out, "\r%s\x1b[K", p)
+}
+
+// Stop stops rendering and clears the status line.
+func (p *progress) Stop() {
+	close(p.stop)
+	<-p.done
+}
+
+// stderrIsTerminal reports whether standard error is a terminal, on which the status
+// line of progress can be redrawn.
+func stderrIsTerminal() bool {
+	fd := os.Stderr.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// countPackages returns the number of packages matched by args, using go list.
+func countPackages(args []string) (int, error) {
+	out, err := exec.Command("go", append([]string{"list"}, args...)...).Output()
+	if err != nil {
+		return 0, err
+	}
+	return len(strings.Fields(string(out))), nil
+}
+
+// packageArgs returns the arguments of go test that are not flags, i.e. packages.
+func packageArgs(args []string) []string {
+	var pkgs []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "-args" || arg == "--args":
+			return pkgs
+		case !strings.HasPrefix(arg, "-"):
+			pkgs = append(pkgs, arg)
+		case !strings.Contains(arg, "=") && goTestValueFlags[strings.TrimPrefix(strings.TrimLeft(arg, "-"), "test.")]:
+			// Skip the value of -flag value.
+			i++
+		}
+	}
+	return pkgs
+}
+
+// progressReadCloser stops rendering progress once the underlying reader is exhausted.
+type progressReadCloser struct {
+	io.ReadCloser
+	prog *progress
+	once sync.Once
+}
+
+func (r *progressReadCloser) Read(b []byte) (int, error) {
+	n, err := r.ReadCloser.Read(b)
+	if err != nil {
+		r.once.Do(r.prog.Stop)
+	}
+	return n, err
+}
+
+func (r *progressReadCloser) Close() error {
+	r.once.Do(r.prog.Stop)
+	return r.ReadCloser.Close()
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPackageArgs(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		args []string
+		want []string
+	}{
+		// 0
+		{[]string{"-race", "./..."}, []string{"./..."}},
+		// 1
+		{[]string{"-count", "1", "-run=TestA", "./a", "./b"}, []string{"./a", "./b"}},
+		// 2
+		{[]string{"-timeout", "5m", "./a", "-args", "b"}, []string{"./a"}},
+		// 3
+		{[]string{"-v"}, nil},
+	}
+
+	for i, test := range tt {
+		if got := packageArgs(test.args); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%d: got %q, want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestProgress(t *testing.T) {
+
+	t.Parallel()
+
+	p := &progress{
+		seen:     make(map[string]bool),
+		complete: make(map[string]bool),
+		running:  make(map[string]bool),
+	}
+	p.Write([]byte(`{"Action":"run","Package":"a","Test":"TestA"}` + "\n" + `{"Action":"run","Package":"b","Test":"TestB"}` + "\n"))
+	p.Write([]byte(`{"Action":"pass","Package":"a","Test":"TestA"}` + "\n" + `{"Action":"pass","Package":"a"}`))
+	// The last line is only counted once complete.
+	if got := p.String(); !strings.HasPrefix(got, "tparse: 0/2 packages, 1 running tests") {
+		t.Errorf("got %q", got)
+	}
+	p.Write([]byte("\n"))
+	if got := p.String(); !strings.HasPrefix(got, "tparse: 1/2 packages, 1 running tests") {
+		t.Errorf("got %q", got)
+	}
+}
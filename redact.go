@@ -0,0 +1,46 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// stringList implements flag.Value for repeatable string flags.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// newRedactor returns the redactor configured by -redact and -redact-pattern, or nil
+// if redaction is disabled.
+func newRedactor() (*parse.Redactor, error) {
+	if !*redactPtr && len(redactPatterns) == 0 {
+		return nil, nil
+	}
+	home, _ := os.UserHomeDir()
+	patterns := append(append([]string(nil), parse.DefaultRedactPatterns...), redactPatterns...)
+	return parse.NewRedactor(patterns, home)
+}
+
+// redactWriter redacts everything written to it. Secrets split across writes are not
+// redacted, so each write should hold whole lines.
+type redactWriter struct {
+	w io.Writer
+	r *parse.Redactor
+}
+
+func (w *redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, w.r.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseSpeed parses a replay speed such as "2x", "0.5" or "10x".
+func parseSpeed(s string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(s), "x"), 64)
+	if err != nil || f <= 0 {
+		return 0, fmt.Errorf("invalid speed %q: must be a positive number, e.g. 2x", s)
+	}
+	return f, nil
+}
+
+// replayJSON re-emits the go test -json output recorded in the named file, such as
+// one saved with -tee, pacing events by their recorded timestamps divided by speed.
+// If tee is non-nil, output is also written to it.
+func replayJSON(name string, speed float64, tee io.Writer) (io.ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		defer f.Close()
+
+		var w io.Writer = pw
+		if tee != nil {
+			w = io.MultiWriter(pw, tee)
+		}
+
+		start := time.Now()
+		var first time.Time
+
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64*1024), 1024*1024*16)
+		for sc.Scan() {
+			var e struct{ Time time.Time }
+			if json.Unmarshal(sc.Bytes(), &e) == nil && !e.Time.IsZero() {
+				if first.IsZero() {
+					first = e.Time
+				}
+				at := time.Duration(float64(e.Time.Sub(first)) / speed)
+				if wait := at - time.Since(start); wait > 0 {
+					time.Sleep(wait)
+				}
+			}
+			if _, err := w.Write(append(sc.Bytes(), '\n')); err != nil {
+				return
+			}
+		}
+		pw.CloseWithError(sc.Err())
+	}()
+
+	return pr, nil
+}
+
+// countRecordedPackages returns the number of packages in the go test -json output
+// recorded in the named file.
+func countRecordedPackages(name string) (int, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	pkgs := make(map[string]bool)
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024*16)
+	for sc.Scan() {
+		var e struct{ Package string }
+		if json.Unmarshal(sc.Bytes(), &e) == nil && e.Package != "" {
+			pkgs[e.Package] = true
+		}
+	}
+	return len(pkgs), sc.Err()
+}
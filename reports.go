@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// reportOptions are the settings of the run that reports depend on.
+type reportOptions struct {
+	// quarantine holds known-flaky tests, whose failures are left out of the failures
+	// of reports and, where the format has no better place for them, reported as skipped.
+	quarantine parse.Quarantine
+	// labels is the metadata attached to the run with -label.
+	labels runLabels
+}
+
+// failed returns the failed tests of pkg, except those excused by the quarantine.
+func (o reportOptions) failed(pkg *parse.Package) []*parse.Test {
+	var failed []*parse.Test
+	for _, t := range pkg.TestsByAction(parse.ActionFail) {
+		if !o.quarantine.Excused(pkg, t) {
+			failed = append(failed, t)
+		}
+	}
+	return failed
+}
+
+// quarantined returns the failed tests of pkg excused by the quarantine.
+func (o reportOptions) quarantined(pkg *parse.Package) []*parse.Test {
+	var quarantined []*parse.Test
+	for _, t := range pkg.TestsByAction(parse.ActionFail) {
+		if o.quarantine.Excused(pkg, t) {
+			quarantined = append(quarantined, t)
+		}
+	}
+	return quarantined
+}
+
+// quarantinedMessage is the message of a quarantined failure reported as skipped.
+func quarantinedMessage(t *parse.Test) string {
+	if m := t.Message(); m != "" {
+		return "quarantined: " + m
+	}
+	return "quarantined"
+}
+
+// reportFormats are the formats of report files, by name.
+var reportFormats = map[string]func(w io.Writer, pkgs parse.Packages, exitCode int, opts reportOptions) error{
+	"markdown": writeMarkdown,
+	"junit": func(w io.Writer, pkgs parse.Packages, _ int, opts reportOptions) error {
+		return writeJUnit(w, pkgs, opts)
+	},
+	"codecov": func(w io.Writer, pkgs parse.Packages, _ int, opts reportOptions) error {
+		return writeCodecov(w, pkgs, opts)
+	},
+	"sonar": func(w io.Writer, pkgs parse.Packages, _ int, opts reportOptions) error {
+		return writeSonar(w, pkgs, opts)
+	},
+	"buildkite": writeBuildkiteAnnotation,
+	"json":      writeSummaryJSON,
+	"badge": func(w io.Writer, pkgs parse.Packages, _ int, _ reportOptions) error {
+		return writeBadgeJSON(w, pkgs)
+	},
+	"badge-svg": func(w io.Writer, pkgs parse.Packages, _ int, _ reportOptions) error {
+		return writeBadgeSVG(w, pkgs)
+	},
+	"failures": func(w io.Writer, pkgs parse.Packages, _ int, opts reportOptions) error {
+		return writeFailedOutput(w, pkgs, opts)
+	},
+}
+
+// reportExtensions are the formats inferred from the extension of an -output-file
+// without format.
+var reportExtensions = map[string]string{
+	".md":   "markdown",
+	".json": "json",
+	".xml":  "junit",
+	".log":  "failures",
+	".svg":  "badge-svg",
+}
+
+// outputFile is a report file requested with -output-file=path:format.
+type outputFile struct {
+	path, format string
+}
+
+// outputFiles implements flag.Value for the repeatable -output-file flag.
+type outputFiles []outputFile
+
+func (o *outputFiles) String() string {
+	var s []string
+	for _, f := range *o {
+		s = append(s, f.path+":"+f.format)
+	}
+	return strings.Join(s, ",")
+}
+
+func (o *outputFiles) Set(value string) error {
+	f := outputFile{path: value}
+	if i := strings.LastIndex(value, ":"); i > 0 {
+		if _, ok := reportFormats[value[i+1:]]; ok || value[i+1:] == "allure" {
+			f.path, f.format = value[:i], value[i+1:]
+		}
+	}
+	if f.format == "" {
+		f.format = reportExtensions[filepath.Ext(f.path)]
+	}
+	if f.format == "" {
+		return fmt.Errorf("no format for %q: use path:format, with format one of %s",
+			value, strings.Join(reportFormatNames(), ", "))
+	}
+	*o = append(*o, f)
+	return nil
+}
+
+func reportFormatNames() []string {
+	names := []string{"allure"}
+	for name := range reportFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeReports writes all reports requested through flags. A failing report is printed
+// to stderr and does not prevent the others; ok is false if any report failed. A
+// failed upload or pull request comment is only a warning, as the reports were written.
+func writeReports(pkgs parse.Packages, exitCode int, opts reportOptions) (ok bool) {
+	ok = true
+	check := func(err error) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tparse error: %v\n", err)
+			ok = false
+		}
+	}
+	warn := func(err error) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tparse warning: %v\n", err)
+		}
+	}
+
+	for _, f := range reportFiles() {
+		if f.format == "allure" {
+			check(writeAllure(f.path, pkgs, opts))
+			continue
+		}
+		write := reportFormats[f.format]
+		check(writeFile(f.path, func(w io.Writer) error {
+			return write(w, pkgs, exitCode, opts)
+		}))
+	}
+
+	if *bkAnnotatePtr {
+		check(buildkiteAnnotate(pkgs, exitCode, opts))
+	}
+	check(writeComment(pkgs, exitCode, opts))
+
+	if *uploadPtr != "" {
+		warn(uploadReports(*uploadPtr, pkgs, exitCode, opts))
+	}
+
+	return ok
+}
+
+// reportFiles returns the report files requested through flags.
+func reportFiles() outputFiles {
+	// The report flags predating -output-file are shorthands for it.
+	files := append(outputFiles(nil), outputFilesFlag...)
+	for _, f := range []outputFile{
+		{*junitPtr, "junit"},
+		{*fullOutputPtr, "failures"},
+		{*codecovPtr, "codecov"},
+		{*sonarPtr, "sonar"},
+		{*allurePtr, "allure"},
+		{*buildkitePtr, "buildkite"},
+	} {
+		if f.path != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// writeComment writes the markdown summary to the -markdown file and posts it as a pull
+// request comment if -github-comment is set. A failed post is only a warning, as it
+// does not affect the test results.
+func writeComment(pkgs parse.Packages, exitCode int, opts reportOptions) error {
+	if *markdownPtr == "" && !*ghCommentPtr {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := writeMarkdown(&buf, pkgs, exitCode, opts); err != nil {
+		return err
+	}
+
+	if *markdownPtr != "" {
+		if err := ioutil.WriteFile(*markdownPtr, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+
+	if *ghCommentPtr {
+		if err := postComment(buf.String()); err != nil {
+			fmt.Fprintf(os.Stderr, "tparse warning: failed to post GitHub comment: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// postComment creates or updates the tparse comment on the pull request.
+func postComment(body string) error {
+	c, err := newGithubClient()
+	if err != nil {
+		return err
+	}
+	pr, err := githubPullRequest(*ghPRPtr)
+	if err != nil {
+		return err
+	}
+	return c.UpsertComment(pr, markdownMarker, body)
+}
+
+// writeFailedOutput writes the complete output of every failed test, and of panicked
+// packages, for -full-output. Quarantined failures are left out.
+func writeFailedOutput(w io.Writer, pkgs parse.Packages, opts reportOptions) error {
+	bw := bufio.NewWriter(w)
+	for _, name := range sortedPackageNames(pkgs) {
+		pkg := pkgs[name]
+
+		if pkg.HasPanic {
+			fmt.Fprintf(bw, "=== PANIC: %s %s\n", name, pkg.Summary.Test)
+			for _, e := range pkg.PanicEvents {
+				bw.WriteString(e.Output)
+			}
+			bw.WriteString("\n")
+			continue
+		}
+
+		for _, t := range opts.failed(pkg) {
+			fmt.Fprintf(bw, "=== FAIL: %s %s (%.2fs)\n", name, t.Name, t.Elapsed())
+			bw.WriteString(t.Output())
+			bw.WriteString("\n")
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+const quarantineEvents = `{"Time":"2024-01-02T15:04:05Z","Action":"run","Package":"example.com/a","Test":"TestFlaky"}
+{"Time":"2024-01-02T15:04:05Z","Action":"output","Package":"example.com/a","Test":"TestFlaky","Output":"    a_test.go:10: flaked\n"}
+{"Time":"2024-01-02T15:04:05Z","Action":"fail","Package":"example.com/a","Test":"TestFlaky","Elapsed":0.1}
+{"Time":"2024-01-02T15:04:05Z","Action":"run","Package":"example.com/a","Test":"TestBroken"}
+{"Time":"2024-01-02T15:04:05Z","Action":"output","Package":"example.com/a","Test":"TestBroken","Output":"    a_test.go:20: broke\n"}
+{"Time":"2024-01-02T15:04:05Z","Action":"fail","Package":"example.com/a","Test":"TestBroken","Elapsed":0.1}
+{"Time":"2024-01-02T15:04:05Z","Action":"fail","Package":"example.com/a","Elapsed":0.2}
+`
+
+func TestReportsQuarantine(t *testing.T) {
+
+	t.Parallel()
+
+	pkgs, err := parse.Process(strings.NewReader(quarantineEvents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := reportOptions{quarantine: parse.Quarantine{parse.NewQuarantineRule("example.com/a", "TestFlaky")}}
+
+	write := func(format string) func() (string, error) {
+		return func() (string, error) {
+			var buf bytes.Buffer
+			err := reportFormats[format](&buf, pkgs, 1, opts)
+			return buf.String(), err
+		}
+	}
+
+	tt := []struct {
+		name    string
+		write   func() (string, error)
+		want    []string
+		wantNot []string
+	}{
+		{
+			"markdown", write("markdown"),
+			[]string{"<code>TestBroken</code> (", "#### QUARANTINED FAILURES\n\n- <code>TestFlaky</code> in <code>example.com/a</code>\n"},
+			[]string{"<code>TestFlaky</code> (", "flaked"},
+		},
+		{
+			"buildkite", write("buildkite"),
+			[]string{"<code>TestBroken</code> in"},
+			[]string{"TestFlaky"},
+		},
+		{
+			"junit", write("junit"),
+			[]string{`<skipped message="quarantined: flaked">`, `<failure message="broke">`},
+			[]string{`<failure message="flaked"`},
+		},
+		{
+			"failures", write("failures"),
+			[]string{"=== FAIL: example.com/a TestBroken"},
+			[]string{"TestFlaky"},
+		},
+		{
+			"html", func() (string, error) {
+				var buf bytes.Buffer
+				err := writeHTMLSummary(&buf, pkgs, 1, opts)
+				return buf.String(), err
+			},
+			[]string{"<code>TestBroken</code>"},
+			[]string{"TestFlaky"},
+		},
+		{
+			"azure", func() (string, error) {
+				var buf bytes.Buffer
+				writeAzureCommands(&buf, pkgs, 1, opts)
+				return buf.String(), nil
+			},
+			[]string{"type=error", "example.com/a: TestBroken failed", "type=warning", "example.com/a: TestFlaky failed (quarantined)"},
+			nil,
+		},
+	}
+
+	for _, test := range tt {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.write()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range test.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("missing %q in\n%s", s, got)
+				}
+			}
+			for _, s := range test.wantNot {
+				if strings.Contains(got, s) {
+					t.Errorf("unexpected %q in\n%s", s, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteCommentPostFailure(t *testing.T) {
+
+	defer os.Setenv("GITHUB_TOKEN", os.Getenv("GITHUB_TOKEN"))
+	os.Unsetenv("GITHUB_TOKEN")
+	defer func(comment bool, markdown string) { *ghCommentPtr, *markdownPtr = comment, markdown }(*ghCommentPtr, *markdownPtr)
+	*ghCommentPtr = true
+	*markdownPtr = filepath.Join(t.TempDir(), "summary.md")
+
+	pkgs, err := parse.Process(strings.NewReader(quarantineEvents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The comment cannot be posted without GITHUB_TOKEN, which must not fail the run.
+	if err := writeComment(pkgs, 1, reportOptions{}); err != nil {
+		t.Errorf("got error %v for a failed post, want a warning", err)
+	}
+	if _, err := os.Stat(*markdownPtr); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReportsLabels(t *testing.T) {
+
+	t.Parallel()
+
+	pkgs, err := parse.Process(strings.NewReader(quarantineEvents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := reportOptions{labels: runLabels{{key: "branch", value: "main"}, {key: "job", value: "go `1.x`"}}}
+
+	tt := []struct {
+		format string
+		want   []string
+	}{
+		// 0
+		{"markdown", []string{"branch: `main` · job: `` go `1.x` ``"}},
+		// 1
+		{"junit", []string{`<property name="branch" value="main"></property>`, `<property name="job" value="go ` + "`1.x`" + `"></property>`}},
+		// 2
+		{"json", []string{`"branch": "main"`, `"job": "go ` + "`1.x`" + `"`, `"package": "example.com/a"`}},
+	}
+
+	for _, test := range tt {
+		t.Run(test.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := reportFormats[test.format](&buf, pkgs, 1, opts); err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range test.want {
+				if !strings.Contains(buf.String(), s) {
+					t.Errorf("missing %q in\n%s", s, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestMarkdownCode(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		in, want string
+	}{
+		// 0
+		{"main", "`main`"},
+		// 1
+		{"a `b` c", "``a `b` c``"},
+		// 2
+		{"`a``", "``` `a`` ```"},
+		// 3
+		{"a\nb", "`a b`"},
+	}
+
+	for i, test := range tt {
+		if got := markdownCode(test.in); got != test.want {
+			t.Errorf("%d: got %q, want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestOutputFilesSet(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		value        string
+		path, format string
+		err          bool
+	}{
+		// 0
+		{"summary.md:markdown", "summary.md", "markdown", false},
+		// 1
+		{"report.xml", "report.xml", "junit", false},
+		// 2
+		{"run.json", "run.json", "json", false},
+		// 3
+		{"coverage.json:badge", "coverage.json", "badge", false},
+		// 4
+		{"allure-results:allure", "allure-results", "allure", false},
+		// 5, a Windows path
+		{`C:\reports\out.log`, `C:\reports\out.log`, "failures", false},
+		// 6
+		{"report.txt", "", "", true},
+		// 7
+		{"report.xml:html", "", "", true},
+	}
+
+	for i, test := range tt {
+		var files outputFiles
+		err := files.Set(test.value)
+		if (err != nil) != test.err {
+			t.Errorf("%d: got error %v, want error %t", i, err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got := files[0]; got.path != test.path || got.format != test.format {
+			t.Errorf("%d: got %s:%s, want %s:%s", i, got.path, got.format, test.path, test.format)
+		}
+	}
+}
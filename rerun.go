@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// rerunCommands returns a go test command for each package of pkgs with failed tests,
+// other than quarantined ones, that reruns them, in order of package. Packages of the
+// current module are given by their directory, e.g. ./parse, so the commands can be
+// pasted at its root.
+func rerunCommands(pkgs parse.Packages, quarantine parse.Quarantine) []string {
+	mod := readModulePath("go.mod")
+
+	var cmds []string
+	for _, name := range sortedPackageNames(pkgs) {
+		pkg := pkgs[name]
+
+		var failed []string
+		for _, t := range pkg.TestsByAction(parse.ActionFail) {
+			if !quarantine.Excused(pkg, t) {
+				failed = append(failed, t.Name)
+			}
+		}
+		if len(failed) == 0 && pkg.HasPanic && pkg.Summary.Test != "" {
+			failed = append(failed, pkg.Summary.Test)
+		}
+		if len(failed) == 0 {
+			continue
+		}
+
+		target := name
+		if dir := packageDir(mod, name); dir == "." {
+			target = "."
+		} else if dir != "" {
+			target = "./" + filepath.ToSlash(dir)
+		}
+		cmds = append(cmds, fmt.Sprintf("go test -run %s %s", shellQuote(parse.PackageRunPattern(failed)), target))
+	}
+	return cmds
+}
+
+// shellQuote quotes s as a single word for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// PrintRerun prints the commands that rerun the failed tests, ready to be pasted.
+func (w *consoleWriter) PrintRerun(cmds []string) {
+	if len(cmds) == 0 {
+		return
+	}
+	fmt.Fprintf(w.Output, "%s\n\n", colorize("\nRerun failed tests:", cYellow, w.Color))
+	for _, cmd := range cmds {
+		fmt.Fprintf(w.Output, "\t%s\n", cmd)
+	}
+}
+
+// writeRerunScript writes the commands that rerun the failed tests to an executable
+// shell script. Every command runs, and the script fails if any of them fails. Without
+// failures the script does nothing.
+func writeRerunScript(name string, cmds []string) error {
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n")
+	sb.WriteString("# Reruns the tests that failed, as reported by tparse.\n")
+	sb.WriteString("status=0\n")
+	for _, cmd := range cmds {
+		sb.WriteString(cmd + " || status=1\n")
+	}
+	sb.WriteString("exit $status\n")
+	return ioutil.WriteFile(name, []byte(sb.String()), 0755)
+}
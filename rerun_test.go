@@ -0,0 +1,137 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+const rerunEvents = `{"Action":"run","Package":"github.com/mfridman/tparse/parse","Test":"TestA"}
+{"Action":"run","Package":"github.com/mfridman/tparse/parse","Test":"TestA/x"}
+{"Action":"fail","Package":"github.com/mfridman/tparse/parse","Test":"TestA/x","Elapsed":0}
+{"Action":"fail","Package":"github.com/mfridman/tparse/parse","Test":"TestA","Elapsed":0}
+{"Action":"run","Package":"github.com/mfridman/tparse/parse","Test":"TestB"}
+{"Action":"run","Package":"github.com/mfridman/tparse/parse","Test":"TestB/it's_broken"}
+{"Action":"fail","Package":"github.com/mfridman/tparse/parse","Test":"TestB/it's_broken","Elapsed":0}
+{"Action":"fail","Package":"github.com/mfridman/tparse/parse","Test":"TestB","Elapsed":0}
+{"Action":"run","Package":"github.com/mfridman/tparse/parse","Test":"TestFlaky"}
+{"Action":"fail","Package":"github.com/mfridman/tparse/parse","Test":"TestFlaky","Elapsed":0}
+{"Action":"fail","Package":"github.com/mfridman/tparse/parse","Elapsed":0}
+{"Action":"run","Package":"example.com/b","Test":"TestC"}
+{"Action":"fail","Package":"example.com/b","Test":"TestC","Elapsed":0}
+{"Action":"fail","Package":"example.com/b","Elapsed":0}
+{"Action":"run","Package":"example.com/c","Test":"TestD"}
+{"Action":"pass","Package":"example.com/c","Test":"TestD","Elapsed":0}
+{"Action":"pass","Package":"example.com/c","Elapsed":0}
+`
+
+func TestRerunCommands(t *testing.T) {
+
+	t.Parallel()
+
+	pkgs, err := parse.Process(strings.NewReader(rerunEvents))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		quarantine parse.Quarantine
+		want       []string
+	}{
+		// 0
+		{nil, []string{
+			`go test -run '^(TestC)$' example.com/b`,
+			`go test -run '^(TestA|TestB|TestFlaky)$/^(it'\''s_broken|x)$' ./parse`,
+		}},
+		// 1
+		{parse.Quarantine{parse.NewQuarantineRule("example.com/b", "TestC"), parse.NewQuarantineRule("github.com/mfridman/tparse/parse", "TestFlaky")}, []string{
+			`go test -run '^(TestA|TestB)$/^(it'\''s_broken|x)$' ./parse`,
+		}},
+	}
+
+	for i, test := range tt {
+		got := rerunCommands(pkgs, test.quarantine)
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%d: got\n%s\nwant\n%s", i, strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		in, want string
+	}{
+		// 0
+		{"^(TestA)$", `'^(TestA)$'`},
+		// 1
+		{"^(TestA)$/^(it's)$", `'^(TestA)$/^(it'\''s)$'`},
+		// 2
+		{"", `''`},
+	}
+
+	for i, test := range tt {
+		if got := shellQuote(test.in); got != test.want {
+			t.Errorf("%d: got %s, want %s", i, got, test.want)
+		}
+	}
+}
+
+func TestWriteRerunScript(t *testing.T) {
+
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "rerun.sh")
+	cmds := []string{`go test -run '^(TestA)$' ./parse`, `go test -run '^(TestB)$' example.com/b`}
+	if err := writeRerunScript(name, cmds); err != nil {
+		t.Fatal(err)
+	}
+	by, err := ioutil.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "#!/bin/sh\n# Reruns the tests that failed, as reported by tparse.\nstatus=0\n" +
+		strings.Join(cmds, " || status=1\n") + " || status=1\nexit $status\n"
+	if string(by) != want {
+		t.Errorf("got\n%s\nwant\n%s", by, want)
+	}
+	fi, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode()&0111 == 0 {
+		t.Errorf("got mode %v, want an executable script", fi.Mode())
+	}
+}
+
+func TestRerunScriptStatus(t *testing.T) {
+
+	t.Parallel()
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh to run the script")
+	}
+
+	dir := t.TempDir()
+	name := filepath.Join(dir, "rerun.sh")
+	marker := filepath.Join(dir, "ran")
+	// The first package fails, yet the second still runs and the script fails.
+	if err := writeRerunScript(name, []string{"false", "touch '" + marker + "'"}); err != nil {
+		t.Fatal(err)
+	}
+	err = exec.Command(sh, name).Run()
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Errorf("got error %v, want a failed script", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("got %v, want the command following the failure run", err)
+	}
+}
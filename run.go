@@ -0,0 +1,182 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// runGoTest starts go test -json with the given arguments and returns its output as
+// it is produced. Standard error is passed through. If tee is non-nil, output is also
+// written to it.
+//
+// Closing the returned reader waits for go test to exit. A non-zero exit status is
+// not an error, because failed tests are reported through the output.
+func runGoTest(args []string, tee io.Writer) (io.ReadCloser, error) {
+	cmd := exec.Command("go", append([]string{"test", "-json"}, args...)...)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var r io.Reader = stdout
+	if tee != nil {
+		r = io.TeeReader(stdout, tee)
+	}
+	return &goTestOutput{Reader: r, cmd: cmd}, nil
+}
+
+// runTestBinary runs the compiled test binary name with -test.v and the given
+// arguments, as go test does, and returns its output converted to go test -json events.
+// Standard output and standard error are merged.
+func runTestBinary(name string, args []string) (io.ReadCloser, error) {
+	cmd := exec.Command(name, append([]string{"-test.v"}, args...)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		err := parse.ConvertText(pw, stdout, binaryPackage(name))
+		if werr := cmd.Wait(); err == nil {
+			if _, ok := werr.(*exec.ExitError); !ok {
+				err = werr
+			}
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr, nil
+}
+
+// binaryPackage returns the package name reported for a test binary, its file name
+// without the .test suffix, as written by go test -c.
+func binaryPackage(name string) string {
+	name = strings.TrimSuffix(filepath.Base(name), ".exe")
+	return strings.TrimSuffix(name, ".test")
+}
+
+// goTestOutput is the output of a running go test command.
+type goTestOutput struct {
+	io.Reader
+	cmd *exec.Cmd
+}
+
+// Close drains any remaining output and waits for the command to exit.
+func (o *goTestOutput) Close() error {
+	io.Copy(ioutil.Discard, o.Reader)
+	if err := o.cmd.Wait(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// goTestValueFlags are the go test and build flags taking a value, which may be given
+// as a separate argument: -flag value.
+var goTestValueFlags = map[string]bool{
+	"asmflags": true, "bench": true, "benchtime": true, "blockprofile": true,
+	"blockprofilerate": true, "buildmode": true, "buildvcs": true, "compiler": true,
+	"count": true, "covermode": true, "coverpkg": true, "coverprofile": true, "cpu": true,
+	"cpuprofile": true, "exec": true, "fuzz": true, "fuzzcachedir": true,
+	"fuzzminimizetime": true, "fuzztime": true, "gccgoflags": true, "gcflags": true,
+	"installsuffix": true, "ldflags": true, "list": true, "memprofile": true,
+	"memprofilerate": true, "mod": true, "modfile": true, "mutexprofile": true,
+	"mutexprofilefraction": true, "o": true, "outputdir": true, "overlay": true, "p": true,
+	"parallel": true, "pgo": true, "pkgdir": true, "run": true, "shuffle": true, "skip": true,
+	"tags": true, "timeout": true, "toolexec": true, "trace": true, "vet": true,
+}
+
+// rerunSkipFlags are the go test flags not forwarded to reruns: -run and -json, which
+// reruns set themselves, and the flags writing files, which reruns would overwrite
+// with the results of the failed tests alone.
+var rerunSkipFlags = map[string]bool{
+	"run": true, "json": true,
+	"blockprofile": true, "coverprofile": true, "cpuprofile": true, "memprofile": true,
+	"mutexprofile": true, "o": true, "outputdir": true, "trace": true,
+}
+
+// rerunFlags returns the flags of the go test arguments args to forward to reruns: all
+// flags, in either the -flag=value or the -flag value form, except rerunSkipFlags. The
+// package arguments are left out, while the arguments following -args, which are
+// passed to the test binary, are kept at the end.
+func rerunFlags(args []string) (flags, binaryArgs []string) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-args" || arg == "--args" {
+			return flags, append([]string{"-args"}, args[i+1:]...)
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "--" {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimLeft(arg, "-"), "test.")
+		value := []string{arg}
+		if !strings.Contains(name, "=") && goTestValueFlags[name] && i+1 < len(args) {
+			i++
+			value = append(value, args[i])
+		}
+		if name = strings.SplitN(name, "=", 2)[0]; rerunSkipFlags[name] {
+			continue
+		}
+		flags = append(flags, value...)
+	}
+	return flags, nil
+}
+
+// rerunFailed re-runs the failed top-level tests of each package up to n times, merging
+// the results into pkgs. Tests that pass on a rerun are marked as flaky.
+//
+// Reruns forward the flags of args, see rerunFlags, followed by the package import path.
+func rerunFailed(pkgs parse.Packages, args []string, n int) error {
+	flags, binaryArgs := rerunFlags(args)
+
+	for i := 0; i < n; i++ {
+		var failed bool
+		for name, pkg := range pkgs {
+			if pkg.HasPanic {
+				continue
+			}
+			tests := pkg.FailedTopLevel()
+			if len(tests) == 0 {
+				continue
+			}
+			failed = true
+
+			rerunArgs := append(append([]string{}, flags...), "-run="+parse.RunPattern(tests), name)
+			rerunArgs = append(rerunArgs, binaryArgs...)
+			r, err := runGoTest(rerunArgs, nil)
+			if err != nil {
+				return err
+			}
+			rerun, err := parse.Process(r)
+			r.Close()
+			if err != nil {
+				// A rerun that cannot be parsed leaves the original results untouched.
+				continue
+			}
+			if rp, ok := rerun[name]; ok {
+				pkg.MergeRerun(rp)
+			}
+		}
+		if !failed {
+			break
+		}
+	}
+
+	return nil
+}
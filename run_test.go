@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRerunFlags(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		args       []string
+		flags      []string
+		binaryArgs []string
+	}{
+		// 0
+		{[]string{"-race", "-count=1", "./..."}, []string{"-race", "-count=1"}, nil},
+		// 1
+		{[]string{"-count", "1", "-timeout", "5m", "./pkg"}, []string{"-count", "1", "-timeout", "5m"}, nil},
+		// 2
+		{[]string{"-run", "TestA", "-v", "./pkg"}, []string{"-v"}, nil},
+		// 3
+		{[]string{"-run=TestA", "-json", "-tags", "integration", "./pkg"}, []string{"-tags", "integration"}, nil},
+		// 4
+		{[]string{"--", "-count", "2", "./pkg"}, []string{"-count", "2"}, nil},
+		// 5
+		{[]string{"-v", "./pkg", "-args", "-update", "x"}, []string{"-v"}, []string{"-args", "-update", "x"}},
+		// 6
+		{[]string{"-test.count", "3", "./pkg"}, []string{"-test.count", "3"}, nil},
+		// 7, flags writing files
+		{
+			[]string{"-coverprofile=c.out", "-cpuprofile", "cpu.out", "-memprofile", "mem.out", "-blockprofile=b.out",
+				"-mutexprofile", "m.out", "-trace", "t.out", "-o", "pkg.test", "-outputdir", "out", "-race", "./pkg"},
+			[]string{"-race"}, nil,
+		},
+	}
+
+	for i, test := range tt {
+		flags, binaryArgs := rerunFlags(test.args)
+		if !reflect.DeepEqual(flags, test.flags) {
+			t.Errorf("%d: got flags %q, want %q", i, flags, test.flags)
+		}
+		if !reflect.DeepEqual(binaryArgs, test.binaryArgs) {
+			t.Errorf("%d: got binary args %q, want %q", i, binaryArgs, test.binaryArgs)
+		}
+	}
+}
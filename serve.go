@@ -0,0 +1,413 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// runServe implements serve mode: it serves an HTML dashboard of the run in the first
+// named file, or stdin, on -addr. Further files are previous runs, aggregated into a
+// history page. Runs in progress are followed, with their events streamed from
+// /events. It returns the exit code.
+func runServe(names []string) int {
+	redactor, err := newRedactor()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "tparse error: %v\n", err)
+		return failureCode(exitTparseError)
+	}
+
+	d := &dashboard{redactor: redactor, hub: newEventHub()}
+	if len(names) == 0 {
+		r, err := newReader()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "tparse error: %v\n", err)
+			return failureCode(exitTparseError)
+		}
+		// Pages parse the input read so far, while events are streamed as read.
+		d.stdin = &lineBuffer{}
+		tr := io.TeeReader(convertReader(r), d.stdin)
+		go d.hub.stream(parse.NewScanner(context.Background(), tr, parse.WithRedactor(redactor)))
+	} else {
+		d.current = &runFile{path: names[0]}
+		for _, name := range names[1:] {
+			d.history = append(d.history, &runFile{path: name})
+		}
+		// Fail early on unreadable input rather than on the first request.
+		if _, err := d.load(d.current); err != nil {
+			fmt.Fprintf(os.Stderr, "tparse error: %v\n", err)
+			return failureCode(exitTparseError)
+		}
+		go d.hub.follow(names[0], redactor)
+	}
+
+	fmt.Fprintf(os.Stderr, "tparse: serving dashboard on http://%s\n", dashboardHost(*addrPtr))
+	if err := http.ListenAndServe(*addrPtr, d.handler()); err != nil {
+		fmt.Fprintf(os.Stderr, "tparse error: %v\n", err)
+		return failureCode(exitTparseError)
+	}
+	return 0
+}
+
+// dashboardHost returns the host of the listen address addr to browse to.
+func dashboardHost(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
+
+// runFile is a file of go test -json output, parsed again whenever it changes, so the
+// dashboard shows the most recent run written to it.
+type runFile struct {
+	path    string
+	modTime time.Time
+	pkgs    parse.Packages
+}
+
+// dashboard serves the results of a run over HTTP.
+type dashboard struct {
+	redactor *parse.Redactor
+
+	// mu is held while handling a request, as parsed packages are not safe for
+	// concurrent use.
+	mu      sync.Mutex
+	current *runFile
+	history []*runFile
+
+	// stdin holds the input read so far when serving stdin, parsed into stdinPkgs.
+	stdin     *lineBuffer
+	stdinLen  int
+	stdinPkgs parse.Packages
+
+	hub *eventHub
+}
+
+// lineBuffer is a buffer safe for concurrent use, holding input as it is read.
+type lineBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lineBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// lines returns a copy of the complete lines written so far.
+func (b *lineBuffer) lines() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p := b.buf.Bytes()
+	return append([]byte(nil), p[:bytes.LastIndexByte(p, '\n')+1]...)
+}
+
+func (d *dashboard) parse(r io.Reader) (parse.Packages, error) {
+	pkgs, err := parse.Process(r, parse.WithRedactor(d.redactor))
+	if err != nil && err != parse.ErrRaceDetected {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+// load returns the packages of f, parsing the file if it changed since last loaded.
+// d.mu must be held, except before serving.
+func (d *dashboard) load(f *runFile) (parse.Packages, error) {
+	fi, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+	if f.pkgs != nil && fi.ModTime().Equal(f.modTime) {
+		return f.pkgs, nil
+	}
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	pkgs, err := d.parse(convertReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", f.path, err)
+	}
+	f.pkgs, f.modTime = pkgs, fi.ModTime()
+	return pkgs, nil
+}
+
+// packages returns the packages of the current run. d.mu must be held.
+func (d *dashboard) packages() (parse.Packages, error) {
+	if d.current != nil {
+		return d.load(d.current)
+	}
+	b := d.stdin.lines()
+	if len(b) == 0 {
+		return parse.Packages{}, nil
+	}
+	if len(b) != d.stdinLen {
+		pkgs, err := d.parse(bytes.NewReader(b))
+		if err == parse.ErrNotParseable {
+			// No events yet, such as while packages build.
+			pkgs, err = parse.Packages{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		d.stdinPkgs, d.stdinLen = pkgs, len(b)
+	}
+	return d.stdinPkgs, nil
+}
+
+func (d *dashboard) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.serveIndex)
+	mux.HandleFunc("/test", d.serveTest)
+	mux.HandleFunc("/history", d.serveHistory)
+	mux.HandleFunc("/events", d.serveEvents)
+	return mux
+}
+
+// dashboardRow is a test in the dashboard tables.
+type dashboardRow struct {
+	Package, Name string
+	Status        parse.Action
+	Elapsed       float64
+	Message       string
+}
+
+// dashboardPackage is a package in the dashboard summary.
+type dashboardPackage struct {
+	Name             string
+	Status           string
+	Elapsed          string
+	Cover            string
+	Pass, Fail, Skip int
+}
+
+func (d *dashboard) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	pkgs, err := d.packages()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	query := strings.TrimSpace(r.FormValue("q"))
+	status := r.FormValue("status")
+
+	var summary []dashboardPackage
+	var rows []dashboardRow
+	for _, name := range sortedPackageNames(pkgs) {
+		pkg := pkgs[name]
+		p := dashboardPackage{
+			Name:    name,
+			Status:  string(pkg.Summary.Action),
+			Elapsed: fmt.Sprintf("%.2fs", pkg.Wall()),
+			Cover:   "--",
+			Pass:    len(pkg.TestsByAction(parse.ActionPass)),
+			Fail:    len(pkg.TestsByAction(parse.ActionFail)),
+			Skip:    len(pkg.TestsByAction(parse.ActionSkip)),
+		}
+		switch {
+		case pkg.HasPanic:
+			p.Status = "panic"
+		case pkg.HasRace:
+			p.Status = "race"
+		case pkg.NoTestFiles:
+			p.Status = "no test files"
+		}
+		if pkg.Cached {
+			p.Elapsed = "(cached)"
+		}
+		if pkg.Cover {
+			p.Cover = fmt.Sprintf("%.1f%%", pkg.Coverage)
+		}
+		summary = append(summary, p)
+
+		for _, t := range pkg.Tests {
+			if t.Name == "" {
+				continue
+			}
+			row := dashboardRow{Package: name, Name: t.Name, Status: t.Status(), Elapsed: t.Elapsed()}
+			if status != "" && string(row.Status) != status {
+				continue
+			}
+			if query != "" && !strings.Contains(strings.ToLower(name+" "+t.Name), strings.ToLower(query)) {
+				continue
+			}
+			if row.Status != parse.ActionPass {
+				row.Message = t.Message()
+			}
+			rows = append(rows, row)
+		}
+	}
+	// Failures first, then the slowest.
+	sort.SliceStable(rows, func(i, j int) bool {
+		if fi, fj := rows[i].Status == parse.ActionFail, rows[j].Status == parse.ActionFail; fi != fj {
+			return fi
+		}
+		return rows[i].Elapsed > rows[j].Elapsed
+	})
+
+	d.render(w, indexTemplate, map[string]interface{}{
+		"History":  len(d.history) > 0,
+		"Packages": summary,
+		"Tests":    rows,
+		"Query":    query,
+		"Status":   status,
+		"Statuses": []string{"fail", "pass", "skip"},
+	})
+}
+
+func (d *dashboard) serveTest(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	pkgs, err := d.packages()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	pkg, ok := pkgs[r.FormValue("pkg")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	t := pkg.GetTest(r.FormValue("name"))
+	if t == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var location string
+	if loc, ok := t.Location(); ok {
+		location = loc.String()
+	}
+	d.render(w, testTemplate, map[string]interface{}{
+		"History":  len(d.history) > 0,
+		"Package":  r.FormValue("pkg"),
+		"Name":     t.Name,
+		"Status":   t.Status(),
+		"Elapsed":  t.Elapsed(),
+		"Location": location,
+		"Message":  t.Message(),
+		"Output":   t.Output(),
+	})
+}
+
+func (d *dashboard) serveHistory(w http.ResponseWriter, r *http.Request) {
+	if len(d.history) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var runs []parse.Packages
+	for _, f := range append([]*runFile{d.current}, d.history...) {
+		pkgs, err := d.load(f)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		runs = append(runs, pkgs)
+	}
+	history := parse.Aggregate(runs)
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].FailureRate() > history[j].FailureRate()
+	})
+
+	d.render(w, historyTemplate, map[string]interface{}{
+		"History": true,
+		"Runs":    len(runs),
+		"Tests":   history,
+	})
+}
+
+func (d *dashboard) render(w http.ResponseWriter, t *template.Template, data interface{}) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := t.Execute(w, data); err != nil {
+		fmt.Fprintf(os.Stderr, "tparse warning: dashboard: %v\n", err)
+	}
+}
+
+var dashboardFuncs = template.FuncMap{
+	"seconds": func(f float64) string { return fmt.Sprintf("%.2fs", f) },
+	"percent": func(f float64) string { return fmt.Sprintf("%.1f%%", f*100) },
+}
+
+const dashboardLayout = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>tparse</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+pre { background: #f6f8fa; padding: 1em; overflow-x: auto; }
+.pass { color: #1a7f37; } .fail, .panic, .race { color: #cf222e; font-weight: bold; } .skip { color: #9a6700; }
+nav a { margin-right: 1em; }
+</style>
+</head>
+<body>
+<nav><a href="/">Run</a>{{if .History}}<a href="/history">History</a>{{end}}</nav>
+{{template "content" .}}
+</body>
+</html>
+`
+
+func dashboardTemplate(content string) *template.Template {
+	t := template.Must(template.New("layout").Funcs(dashboardFuncs).Parse(dashboardLayout))
+	return template.Must(t.New("content").Parse(content))
+}
+
+var indexTemplate = dashboardTemplate(`
+<h1>Packages</h1>
+<table>
+<tr><th>Status</th><th>Elapsed</th><th>Package</th><th>Cover</th><th>Pass</th><th>Fail</th><th>Skip</th></tr>
+{{range .Packages}}<tr><td class="{{.Status}}">{{.Status}}</td><td>{{.Elapsed}}</td><td>{{.Name}}</td><td>{{.Cover}}</td><td>{{.Pass}}</td><td>{{.Fail}}</td><td>{{.Skip}}</td></tr>
+{{end}}</table>
+<h1>Tests</h1>
+<form>
+<input name="q" value="{{.Query}}" placeholder="Search tests">
+<select name="status"><option value="">all</option>{{$status := .Status}}{{range .Statuses}}<option{{if eq . $status}} selected{{end}}>{{.}}</option>{{end}}</select>
+<button>Filter</button>
+</form>
+<table>
+<tr><th>Status</th><th>Elapsed</th><th>Test</th><th>Package</th><th>Message</th></tr>
+{{range .Tests}}<tr><td class="{{.Status}}">{{.Status}}</td><td>{{seconds .Elapsed}}</td><td><a href="/test?pkg={{.Package}}&amp;name={{.Name}}">{{.Name}}</a></td><td>{{.Package}}</td><td>{{.Message}}</td></tr>
+{{else}}<tr><td colspan="5">No tests match.</td></tr>
+{{end}}</table>
+`)
+
+var testTemplate = dashboardTemplate(`
+<h1>{{.Name}}</h1>
+<p>{{.Package}} · <span class="{{.Status}}">{{.Status}}</span> in {{seconds .Elapsed}}{{if .Location}} · {{.Location}}{{end}}</p>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+<pre>{{.Output}}</pre>
+`)
+
+var historyTemplate = dashboardTemplate(`
+<h1>History of {{.Runs}} runs</h1>
+<table>
+<tr><th>Test</th><th>Package</th><th>Runs</th><th>Fail</th><th>Mean</th><th>StdDev</th></tr>
+{{range .Tests}}<tr><td>{{.Name}}</td><td>{{.Package}}</td><td>{{.Runs}}</td><td>{{percent .FailureRate}}</td><td>{{seconds .Mean}}</td><td>{{seconds .StdDev}}</td></tr>
+{{end}}</table>
+`)
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDashboard(t *testing.T) {
+
+	t.Parallel()
+
+	d := &dashboard{
+		current: &runFile{path: "parse/testdata/race/input01.json"},
+		history: []*runFile{{path: "parse/testdata/cached_test.json"}},
+		hub:     newEventHub(),
+	}
+	srv := httptest.NewServer(d.handler())
+	defer srv.Close()
+
+	tt := []struct {
+		path   string
+		status int
+		want   []string
+	}{
+		// 0, a data race fails the package
+		{"/", http.StatusOK, []string{`<td class="race">race</td>`, "TestRace1", "command-line-arguments"}},
+		// 1
+		{"/?status=pass&q=testa", http.StatusOK, []string{"TestA"}},
+		// 2
+		{"/?status=skip", http.StatusOK, []string{"No tests match."}},
+		// 3
+		{"/test?pkg=command-line-arguments&name=TestRace1", http.StatusOK, []string{"<h1>TestRace1</h1>"}},
+		// 4
+		{"/test?pkg=command-line-arguments&name=TestMissing", http.StatusNotFound, nil},
+		// 5
+		{"/history", http.StatusOK, []string{"TestRace1", "100.0%"}},
+		// 6
+		{"/missing", http.StatusNotFound, nil},
+	}
+
+	for _, test := range tt {
+		resp, err := http.Get(srv.URL + test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != test.status {
+			t.Errorf("GET %s: got status %d, want %d", test.path, resp.StatusCode, test.status)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(string(body), want) {
+				t.Errorf("GET %s: got no %q in\n%s", test.path, want, body)
+			}
+		}
+	}
+}
+
+func TestDashboardStdin(t *testing.T) {
+
+	t.Parallel()
+
+	d := &dashboard{stdin: &lineBuffer{}, hub: newEventHub()}
+	srv := httptest.NewServer(d.handler())
+	defer srv.Close()
+
+	get := func() string {
+		t.Helper()
+		resp, err := http.Get(srv.URL + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	// No events yet, as while packages build.
+	if body := get(); !strings.Contains(body, "No tests match.") {
+		t.Errorf("got\n%s\nwant no tests", body)
+	}
+	// A line still being written is left out.
+	d.stdin.Write([]byte(`{"Action":"run","Package":"p","Test":"TestA"}` + "\n" + `{"Action":"pass","Pack`))
+	if body := get(); !strings.Contains(body, "TestA") {
+		t.Errorf("got\n%s\nwant TestA", body)
+	}
+}
+
+func TestDashboardHost(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		addr, want string
+	}{
+		{"localhost:8080", "localhost:8080"}, // 0
+		{":8080", "localhost:8080"},          // 1
+		{"0.0.0.0:80", "0.0.0.0:80"},         // 2
+	}
+
+	for i, test := range tt {
+		if got := dashboardHost(test.addr); got != test.want {
+			t.Errorf("%d: got %q, want %q", i, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+	"github.com/olekukonko/tablewriter"
+)
+
+// EnvSkipsTable prints the tests skipped because their environment is missing, such
+// as an unset variable or an unavailable tool, grouped by the missing requirement,
+// most skipped first.
+func (w *consoleWriter) EnvSkipsTable(pkgs parse.Packages, trim bool) {
+	skipped := make(map[string][]string)
+	for _, name := range sortedPackageNames(pkgs) {
+		for _, t := range pkgs[name].TestsByAction(parse.ActionSkip) {
+			if req := t.SkipRequirement(); req != "" {
+				skipped[req] = append(skipped[req], filepath.Base(name)+"."+testName(t.Name, trim))
+			}
+		}
+	}
+	if len(skipped) == 0 {
+		return
+	}
+
+	reqs := make([]string, 0, len(skipped))
+	for req := range skipped {
+		reqs = append(reqs, req)
+	}
+	sort.Slice(reqs, func(i, j int) bool {
+		if ni, nj := len(skipped[reqs[i]]), len(skipped[reqs[j]]); ni != nj {
+			return ni > nj
+		}
+		return reqs[i] < reqs[j]
+	})
+
+	s := "\nSkipped for missing environment"
+	fmt.Fprintf(w.Output, "%s\n\n", colorize(s, cYellow, w.Color))
+
+	tbl := tablewriter.NewWriter(w.Output)
+
+	tbl.SetHeader([]string{
+		"Requirement",
+		"Skipped",
+		"Tests",
+	})
+
+	tbl.SetAutoWrapText(false)
+
+	for _, req := range reqs {
+		tbl.Append([]string{
+			req,
+			strconv.Itoa(len(skipped[req])),
+			strings.Join(skipped[req], "\n"),
+		})
+	}
+
+	tbl.Render()
+}
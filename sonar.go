@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// SonarQube generic test execution report, see
+// https://docs.sonarsource.com/sonarqube/latest/analyzing-source-code/test-coverage/generic-test-data/
+
+type sonarTestExecutions struct {
+	XMLName xml.Name    `xml:"testExecutions"`
+	Version int         `xml:"version,attr"`
+	Files   []sonarFile `xml:"file"`
+}
+
+type sonarFile struct {
+	Path  string          `xml:"path,attr"`
+	Cases []sonarTestCase `xml:"testCase"`
+}
+
+type sonarTestCase struct {
+	Name     string        `xml:"name,attr"`
+	Duration int64         `xml:"duration,attr"`
+	Failure  *sonarMessage `xml:"failure,omitempty"`
+	Error    *sonarMessage `xml:"error,omitempty"`
+	Skipped  *sonarMessage `xml:"skipped,omitempty"`
+}
+
+type sonarMessage struct {
+	Message  string `xml:"message,attr"`
+	Contents string `xml:",cdata"`
+}
+
+// writeSonar writes pkgs as a SonarQube generic test execution report. Every test
+// case must belong to a file: a test is attributed to the _test.go file referenced in
+// its output, or in the output of its parent, or else the file declaring its top-level
+// test function. Tests that cannot be attributed are left out. Quarantined failures are
+// reported as skipped.
+func writeSonar(w io.Writer, pkgs parse.Packages, opts reportOptions) error {
+	mod := readModulePath("go.mod")
+
+	files := make(map[string]*sonarFile)
+	add := func(path string, tc sonarTestCase) {
+		f, ok := files[path]
+		if !ok {
+			f = &sonarFile{Path: path}
+			files[path] = f
+		}
+		f.Cases = append(f.Cases, tc)
+	}
+
+	for _, name := range sortedPackageNames(pkgs) {
+		pkg := pkgs[name]
+
+		var declared map[string]string
+		testFile := func(t *parse.Test) string {
+			for test := t.Name; test != ""; test = parentTest(test) {
+				if parent := pkg.GetTest(test); parent != nil {
+					if loc, ok := parent.Location(); ok && strings.HasSuffix(loc.File, "_test.go") {
+						return sourceFile(mod, name, loc.File)
+					}
+				}
+			}
+			if declared == nil {
+				declared = declaredTests(packageDir(mod, name))
+			}
+			if file, ok := declared[topLevelTest(t.Name)]; ok {
+				return sourceFile(mod, name, file)
+			}
+			return ""
+		}
+
+		if pkg.HasPanic {
+			var out strings.Builder
+			for _, e := range pkg.PanicEvents {
+				out.WriteString(e.Output)
+			}
+			t := pkg.GetTest(pkg.Summary.Test)
+			if t == nil {
+				continue
+			}
+			if path := testFile(t); path != "" {
+				add(path, sonarTestCase{
+					Name:  t.Name,
+					Error: &sonarMessage{Message: "panic", Contents: xmlSafe(out.String())},
+				})
+			}
+			continue
+		}
+
+		for _, t := range pkg.Tests {
+			if t.Name == "" {
+				continue
+			}
+			path := testFile(t)
+			if path == "" {
+				continue
+			}
+			tc := sonarTestCase{
+				Name:     t.Name,
+				Duration: int64(t.Elapsed() * 1000),
+			}
+			switch t.Status() {
+			case parse.ActionFail:
+				if opts.quarantine.Excused(pkg, t) {
+					tc.Skipped = &sonarMessage{Message: xmlSafe(quarantinedMessage(t)), Contents: xmlSafe(t.Stack())}
+					break
+				}
+				tc.Failure = &sonarMessage{Message: xmlSafe(t.Message()), Contents: xmlSafe(t.Stack())}
+			case parse.ActionSkip:
+				tc.Skipped = &sonarMessage{Message: xmlSafe(t.Message())}
+			}
+			add(path, tc)
+		}
+	}
+
+	report := sonarTestExecutions{Version: 1}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		report.Files = append(report.Files, *files[path])
+	}
+
+	return writeXML(w, report)
+}
+
+// parentTest returns the name of the parent of a subtest, or an empty string.
+func parentTest(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+func topLevelTest(name string) string {
+	if i := strings.Index(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// packageDir returns the directory of package pkg relative to the root of module mod,
+// or an empty string if pkg is not part of mod.
+func packageDir(mod, pkg string) string {
+	if mod == "" {
+		return ""
+	}
+	if pkg == mod {
+		return "."
+	}
+	if strings.HasPrefix(pkg, mod+"/") {
+		return filepath.FromSlash(strings.TrimPrefix(pkg, mod+"/"))
+	}
+	return ""
+}
+
+var testFuncRe = regexp.MustCompile(`^func\s+((?:Test|Example|Benchmark|Fuzz)\w*)\s*\(`)
+
+// declaredTests maps the top-level test functions declared in the _test.go files of dir
+// to the base name of their file.
+func declaredTests(dir string) map[string]string {
+	declared := make(map[string]string)
+	if dir == "" {
+		return declared
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	for _, file := range matches {
+		f, err := os.Open(file)
+		if err != nil {
+			continue
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			if m := testFuncRe.FindStringSubmatch(sc.Text()); m != nil {
+				declared[m[1]] = filepath.Base(file)
+			}
+		}
+		f.Close()
+	}
+	return declared
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPackageDir(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		mod, pkg, want string
+	}{
+		// 0
+		{"github.com/mfridman/tparse", "github.com/mfridman/tparse", "."},
+		// 1
+		{"github.com/mfridman/tparse", "github.com/mfridman/tparse/parse", "parse"},
+		// 2
+		{"github.com/mfridman/tparse", "github.com/mfridman/tparse/a/b", filepath.FromSlash("a/b")},
+		// 3, a module sharing the prefix
+		{"github.com/mfridman/tparse", "github.com/mfridman/tparsex", ""},
+		// 4
+		{"", "github.com/mfridman/tparse", ""},
+	}
+
+	for i, test := range tt {
+		if got := packageDir(test.mod, test.pkg); got != test.want {
+			t.Errorf("%d: got %q, want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestTestNames(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		name, parent, top string
+	}{
+		// 0
+		{"TestA", "", "TestA"},
+		// 1
+		{"TestA/x", "TestA", "TestA"},
+		// 2
+		{"TestA/x/y", "TestA/x", "TestA"},
+	}
+
+	for i, test := range tt {
+		if got := parentTest(test.name); got != test.parent {
+			t.Errorf("%d: got parent %q, want %q", i, got, test.parent)
+		}
+		if got := topLevelTest(test.name); got != test.top {
+			t.Errorf("%d: got top-level test %q, want %q", i, got, test.top)
+		}
+	}
+}
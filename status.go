@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// statusLabels maps statuses (pass, fail, skip, panic, notest and flaky) to the label
+// printed for them in tables.
+type statusLabels map[string]string
+
+// statusStyles are the presets for -status-style.
+var statusStyles = map[string]statusLabels{
+	"words": {
+		"pass":   "PASS",
+		"fail":   "FAIL",
+		"skip":   "SKIP",
+		"panic":  "PANIC",
+		"notest": "NOTEST",
+		"flaky":  "FLAKY",
+	},
+	"symbols": {
+		"pass":   "✓",
+		"fail":   "✗",
+		"skip":   "−",
+		"panic":  "‼",
+		"notest": "∅",
+		"flaky":  "~",
+	},
+	"emoji": {
+		"pass":   "✅",
+		"fail":   "❌",
+		"skip":   "⏭️",
+		"panic":  "💥",
+		"notest": "⚪",
+		"flaky":  "🔁",
+	},
+}
+
+// status holds the labels in use, set from -status-style.
+var status = statusStyles["words"]
+
+// parseStatusStyle parses a -status-style value: a preset name, optionally followed by
+// comma-separated overrides such as "symbols,fail=FAILED". A value of only overrides
+// applies them to the "words" preset.
+func parseStatusStyle(s string) (statusLabels, error) {
+	labels := make(statusLabels)
+	for k, v := range statusStyles["words"] {
+		labels[k] = v
+	}
+	if s == "" {
+		return labels, nil
+	}
+
+	for i, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) == 1 {
+			preset, ok := statusStyles[field]
+			if !ok || i > 0 {
+				return nil, fmt.Errorf("unknown status style %q: must be one of %s, followed by overrides",
+					field, strings.Join(presetNames(), ", "))
+			}
+			for k, v := range preset {
+				labels[k] = v
+			}
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		if _, ok := labels[key]; !ok {
+			return nil, fmt.Errorf("unknown status %q in status style", kv[0])
+		}
+		labels[key] = kv[1]
+	}
+	return labels, nil
+}
+
+func presetNames() []string {
+	names := make([]string, 0, len(statusStyles))
+	for name := range statusStyles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// label returns the label for the status, or the status in upper case if it has none.
+func (l statusLabels) label(key string) string {
+	if s, ok := l[key]; ok {
+		return s
+	}
+	return strings.ToUpper(key)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseStatusStyle(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		style      string
+		fail, pass string
+		err        bool
+	}{
+		// 0
+		{"", "FAIL", "PASS", false},
+		// 1
+		{"symbols", "✗", "✓", false},
+		// 2
+		{"emoji,fail=FAILED", "FAILED", "✅", false},
+		// 3, overrides of the words preset
+		{"pass=ok, FAIL=not ok", "not ok", "ok", false},
+		// 4
+		{"fancy", "", "", true},
+		// 5, a preset after overrides
+		{"fail=x,symbols", "", "", true},
+		// 6
+		{"words,broken=x", "", "", true},
+	}
+
+	for i, test := range tt {
+		labels, err := parseStatusStyle(test.style)
+		if (err != nil) != test.err {
+			t.Fatalf("%d: got error %v, want error %t", i, err, test.err)
+		}
+		if err != nil {
+			continue
+		}
+		if got := labels.label("fail"); got != test.fail {
+			t.Errorf("%d: got fail label %q, want %q", i, got, test.fail)
+		}
+		if got := labels.label("pass"); got != test.pass {
+			t.Errorf("%d: got pass label %q, want %q", i, got, test.pass)
+		}
+		if got := labels.label("unknown"); got != "UNKNOWN" {
+			t.Errorf("%d: got label %q for an unknown status, want UNKNOWN", i, got)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+const (
+	// followInterval is how often a followed file is checked for new output.
+	followInterval = 500 * time.Millisecond
+	// followIdle is how long a followed file must not grow after the final event of a
+	// package for its run to be taken as done.
+	followIdle = 3 * time.Second
+)
+
+// eventHub broadcasts messages to the subscribers of the dashboard event stream.
+// Messages are dropped for subscribers that fall behind, rather than stalling the run.
+type eventHub struct {
+	mu      sync.Mutex
+	subs    map[chan streamMessage]bool
+	summary *streamMessage
+}
+
+// streamMessage is a server-sent event: a parsed test event or a summary of the run.
+type streamMessage struct {
+	name string
+	data []byte
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{subs: make(map[chan streamMessage]bool)}
+}
+
+// subscribe returns a channel receiving messages, starting with the latest summary.
+func (h *eventHub) subscribe() chan streamMessage {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan streamMessage, 256)
+	if h.summary != nil {
+		ch <- *h.summary
+	}
+	h.subs[ch] = true
+	return ch
+}
+
+func (h *eventHub) unsubscribe(ch chan streamMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs, ch)
+}
+
+// publish sends the message named name, with v encoded as JSON, to all subscribers.
+func (h *eventHub) publish(name string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	msg := streamMessage{name, data}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if name == "summary" {
+		h.summary = &msg
+	}
+	for ch := range h.subs {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
+
+// streamEvent is the JSON of an event message, a go test -json event with empty
+// fields left out.
+type streamEvent struct {
+	Time    *time.Time   `json:",omitempty"`
+	Action  parse.Action `json:",omitempty"`
+	Package string       `json:",omitempty"`
+	Test    string       `json:",omitempty"`
+	Elapsed float64      `json:",omitempty"`
+	Output  string       `json:",omitempty"`
+}
+
+// streamSummary is the JSON of a summary message, the test counts of the run so far.
+type streamSummary struct {
+	Status   parse.Action `json:"status"`
+	Done     bool         `json:"done"`
+	Packages int          `json:"packages"`
+	Passed   int          `json:"passed"`
+	Failed   int          `json:"failed"`
+	Skipped  int          `json:"skipped"`
+}
+
+func newStreamSummary(pkgs parse.Packages, done bool) streamSummary {
+	totals := pkgs.Totals()
+	status := parse.ActionPass
+	if pkgs.ExitCode() != 0 || totals.Failed > 0 {
+		status = parse.ActionFail
+	}
+	return streamSummary{
+		Status:   status,
+		Done:     done,
+		Packages: totals.Packages,
+		Passed:   totals.Passed,
+		Failed:   totals.Failed,
+		Skipped:  totals.Skipped,
+	}
+}
+
+// stream publishes the events of s as they are parsed, followed by a summary whenever
+// a test or package completes, and a final summary at the end.
+func (h *eventHub) stream(s *parse.Scanner) {
+	for s.Scan() {
+		e := s.Event()
+		msg := streamEvent{
+			Action:  e.Action,
+			Package: e.Package,
+			Test:    e.Test,
+			Elapsed: e.Elapsed,
+			Output:  e.Output,
+		}
+		if !e.Time.IsZero() {
+			msg.Time = &e.Time
+		}
+		h.publish("event", msg)
+		if s.Test() != nil || e.LastLine() {
+			h.publish("summary", newStreamSummary(s.Packages(), false))
+		}
+	}
+	if err := s.Err(); err != nil && err != parse.ErrRaceDetected {
+		fmt.Fprintf(os.Stderr, "tparse warning: event stream: %v\n", err)
+	}
+	h.publish("summary", newStreamSummary(s.Packages(), true))
+}
+
+// follow streams the events written to the file name as it grows, as with tail -f.
+// A run ends once the file stops growing after the final event of a package, and
+// output appended later is streamed as a new run. When the file is truncated, as when
+// a new run is written to it, streaming starts over from the beginning.
+func (h *eventHub) follow(name string, redactor *parse.Redactor) {
+	for {
+		f, err := os.Open(name)
+		if err != nil {
+			time.Sleep(followInterval)
+			continue
+		}
+		r := &followReader{f: f, idle: followIdle}
+		for {
+			h.stream(parse.NewScanner(context.Background(), r, parse.WithRedactor(redactor)))
+			if r.truncated || !r.wait() {
+				break
+			}
+			r.ended = false
+		}
+		f.Close()
+	}
+}
+
+// followReader reads a file that is being written, waiting for more data at its end.
+// It returns io.EOF once the file is truncated below what was already read, setting
+// truncated, or once the file has not grown for idle after the final event of a
+// package, setting ended.
+type followReader struct {
+	f    *os.File
+	idle time.Duration
+
+	read      int64
+	line      []byte // the last line read, or the start of the line being read
+	partial   bool   // whether line is incomplete, without its newline
+	lastRead  time.Time
+	ended     bool
+	truncated bool
+}
+
+func (r *followReader) Read(p []byte) (int, error) {
+	if r.ended || r.truncated {
+		return 0, io.EOF
+	}
+	for {
+		n, err := r.f.Read(p)
+		r.read += int64(n)
+		if n > 0 {
+			r.track(p[:n])
+			r.lastRead = time.Now()
+		}
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+		if fi, err := r.f.Stat(); err != nil || fi.Size() < r.read {
+			r.truncated = true
+			return 0, io.EOF
+		}
+		// A line still being written does not decode.
+		if finalEvent(r.line) && time.Since(r.lastRead) >= r.idle {
+			r.ended = true
+			return 0, io.EOF
+		}
+		time.Sleep(followInterval)
+	}
+}
+
+// track records the last line of the data read.
+func (r *followReader) track(p []byte) {
+	if !r.partial {
+		r.line = r.line[:0]
+	}
+	r.line = append(r.line, p...)
+	r.partial = !bytes.HasSuffix(r.line, []byte("\n"))
+	if i := bytes.LastIndexByte(bytes.TrimSuffix(r.line, []byte("\n")), '\n'); i >= 0 {
+		r.line = append(r.line[:0], r.line[i+1:]...)
+	}
+}
+
+// wait blocks until the file is written again after a run ended, reporting whether
+// the new output follows what was read rather than replacing it.
+func (r *followReader) wait() bool {
+	for {
+		fi, err := r.f.Stat()
+		if err != nil || fi.Size() < r.read {
+			return false
+		}
+		if fi.Size() > r.read {
+			return true
+		}
+		time.Sleep(followInterval)
+	}
+}
+
+// finalEvent reports whether line is the final event of a package, which ends the
+// run if no more output follows.
+func finalEvent(line []byte) bool {
+	e, err := parse.NewEvent(line)
+	if err != nil {
+		return false
+	}
+	return e.LastLine() || (e.Test == "" && e.Output == "" && e.Action == parse.ActionSkip)
+}
+
+// serveEvents streams the events and summaries of the run as server-sent events, named
+// "event" and "summary", with JSON data.
+func (d *dashboard) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	ch := d.hub.subscribe()
+	defer d.hub.unsubscribe(ch)
+	flusher.Flush()
+
+	for {
+		select {
+		case msg := <-ch:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", msg.name, msg.data)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
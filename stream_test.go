@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+func TestEventHub(t *testing.T) {
+
+	t.Parallel()
+
+	h := newEventHub()
+	early := h.subscribe()
+	h.publish("event", streamEvent{Action: parse.ActionRun, Test: "TestA"})
+	h.publish("summary", streamSummary{Status: parse.ActionPass, Passed: 1})
+
+	if msg := <-early; msg.name != "event" || !strings.Contains(string(msg.data), `"Test":"TestA"`) {
+		t.Errorf("got message %s %s, want the event", msg.name, msg.data)
+	}
+	if msg := <-early; msg.name != "summary" {
+		t.Errorf("got message %s, want summary", msg.name)
+	}
+
+	// A new subscriber starts with the latest summary.
+	late := h.subscribe()
+	if msg := <-late; msg.name != "summary" || !strings.Contains(string(msg.data), `"passed":1`) {
+		t.Errorf("got message %s %s, want the latest summary", msg.name, msg.data)
+	}
+
+	// Subscribers that fall behind lose messages rather than block publishing.
+	for i := 0; i < cap(late)+10; i++ {
+		h.publish("event", streamEvent{Action: parse.ActionOutput})
+	}
+	if len(late) != cap(late) {
+		t.Errorf("got %d queued messages, want %d", len(late), cap(late))
+	}
+
+	h.unsubscribe(early)
+	h.unsubscribe(late)
+	if len(h.subs) != 0 {
+		t.Errorf("got %d subscribers after unsubscribing", len(h.subs))
+	}
+}
+
+func TestEventHubStream(t *testing.T) {
+
+	t.Parallel()
+
+	f, err := os.Open("parse/testdata/race/input01.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	h := newEventHub()
+	ch := h.subscribe()
+	h.stream(parse.NewScanner(context.Background(), f))
+
+	var events int
+	var last streamMessage
+	for len(ch) > 0 {
+		last = <-ch
+		if last.name == "event" {
+			events++
+		}
+	}
+	if events == 0 {
+		t.Error("got no events")
+	}
+	var summary streamSummary
+	if err := json.Unmarshal(last.data, &summary); err != nil {
+		t.Fatal(err)
+	}
+	// A data race fails the run.
+	if last.name != "summary" || !summary.Done || summary.Status != parse.ActionFail {
+		t.Errorf("got last message %s %s, want a failed final summary", last.name, last.data)
+	}
+}
+
+func TestFollowReaderEnd(t *testing.T) {
+
+	t.Parallel()
+
+	// The file ends with the final event of its package, so the run ends at once.
+	f, err := os.Open("parse/testdata/cached_test.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r := &followReader{f: f}
+	by, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(by)) != fi.Size() {
+		t.Errorf("got %d bytes, want %d", len(by), fi.Size())
+	}
+	if !r.ended || r.truncated {
+		t.Errorf("got ended %v and truncated %v, want ended", r.ended, r.truncated)
+	}
+}
+
+func TestFollowReaderTruncate(t *testing.T) {
+
+	t.Parallel()
+
+	name := filepath.Join(t.TempDir(), "run.json")
+	run := `{"Action":"run","Package":"p","Test":"TestA"}` + "\n"
+	if err := ioutil.WriteFile(name, []byte(run), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r := &followReader{f: f}
+	p := make([]byte, 1024)
+	if n, err := r.Read(p); err != nil || string(p[:n]) != run {
+		t.Fatalf("got %q, %v, want the run", p[:n], err)
+	}
+
+	// The run has not ended, so the reader waits until the file is truncated.
+	if err := os.Truncate(name, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := r.Read(p); n != 0 || err == nil {
+		t.Errorf("got %d bytes, %v after truncation, want EOF", n, err)
+	}
+	if !r.truncated || r.ended {
+		t.Errorf("got ended %v and truncated %v, want truncated", r.ended, r.truncated)
+	}
+}
+
+func TestFollowReaderTrack(t *testing.T) {
+
+	t.Parallel()
+
+	tt := []struct {
+		chunks  []string
+		line    string
+		partial bool
+	}{
+		// 0
+		{[]string{"a\nb\n"}, "b\n", false},
+		// 1
+		{[]string{"a\nb"}, "b", true},
+		// 2
+		{[]string{"a\nb", "c\n"}, "bc\n", false},
+		// 3
+		{[]string{"a\n", "b", "c", "d\ne"}, "e", true},
+	}
+
+	for i, test := range tt {
+		r := new(followReader)
+		for _, c := range test.chunks {
+			r.track([]byte(c))
+		}
+		if string(r.line) != test.line || r.partial != test.partial {
+			t.Errorf("%d: got line %q (partial %v), want %q (partial %v)", i, r.line, r.partial, test.line, test.partial)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// groupSuiteFailures returns the failed tests of the package with the methods of a
+// testify suite that failed alike, as they do when SetupTest fails, reported once
+// against the suite: only the first method of each fixture failure is kept, and it is
+// returned in names under the name of the suite.
+func groupSuiteFailures(pkg *parse.Package, failed []*parse.Test) ([]*parse.Test, map[*parse.Test]string) {
+	names := make(map[*parse.Test]string)
+	grouped := make(map[*parse.Test]bool)
+	for _, s := range pkg.Suites() {
+		for _, f := range s.FixtureFailures() {
+			names[f.Methods[0]] = fmt.Sprintf("%s (shared failure, %d tests)", s.Test.Name, len(f.Methods))
+			for _, t := range f.Methods[1:] {
+				grouped[t] = true
+			}
+		}
+	}
+
+	kept := failed[:0:0]
+	for _, t := range failed {
+		if !grouped[t] {
+			kept = append(kept, t)
+		}
+	}
+	return kept, names
+}
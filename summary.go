@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// runSummary is the JSON summary of a run, written by the json report format and
+// uploaded as summary.json.
+type runSummary struct {
+	Branch string `json:"branch,omitempty"`
+	Commit string `json:"commit,omitempty"`
+	Run    string `json:"run"`
+
+	Status   parse.Action      `json:"status"`
+	Packages int               `json:"packages"`
+	Passed   int               `json:"passed"`
+	Failed   int               `json:"failed"`
+	Skipped  int               `json:"skipped"`
+	Elapsed  float64           `json:"elapsed"`
+	Labels   map[string]string `json:"labels,omitempty"`
+
+	Results []packageSummary `json:"results"`
+}
+
+type packageSummary struct {
+	Package  string       `json:"package"`
+	Status   parse.Action `json:"status"`
+	Elapsed  float64      `json:"elapsed"`
+	Cached   bool         `json:"cached,omitempty"`
+	Coverage float64      `json:"coverage"`
+	Passed   int          `json:"passed"`
+	Failed   int          `json:"failed"`
+	Skipped  int          `json:"skipped"`
+}
+
+// writeSummaryJSON writes the status and test counts of the run, overall and by
+// package, along with the -label metadata.
+func writeSummaryJSON(w io.Writer, pkgs parse.Packages, exitCode int, opts reportOptions) error {
+	totals := pkgs.Totals()
+	summary := runSummary{
+		Branch:   ciValue("branch", opts.labels),
+		Commit:   ciValue("commit", opts.labels),
+		Run:      ciValue("run", opts.labels),
+		Status:   parse.ActionPass,
+		Packages: totals.Packages,
+		Passed:   totals.Passed,
+		Failed:   totals.Failed,
+		Skipped:  totals.Skipped,
+		Elapsed:  pkgs.Wall(),
+		Results:  []packageSummary{},
+	}
+	if exitCode != 0 {
+		summary.Status = parse.ActionFail
+	}
+	if len(opts.labels) > 0 {
+		summary.Labels = make(map[string]string)
+		for _, l := range opts.labels {
+			summary.Labels[l.key] = l.value
+		}
+	}
+	for _, name := range sortedPackageNames(pkgs) {
+		pkg := pkgs[name]
+		summary.Results = append(summary.Results, packageSummary{
+			Package:  name,
+			Status:   pkg.Summary.Action,
+			Elapsed:  pkg.Summary.Elapsed,
+			Cached:   pkg.Cached,
+			Coverage: pkg.Coverage,
+			Passed:   len(pkg.TestsByAction(parse.ActionPass)),
+			Failed:   len(pkg.TestsByAction(parse.ActionFail)),
+			Skipped:  len(pkg.TestsByAction(parse.ActionSkip)),
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(summary)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/mfridman/tparse/parse"
+	"github.com/olekukonko/tablewriter"
+)
+
+// untestedPackages returns the packages go test reported as [no test files], sorted.
+func untestedPackages(pkgs parse.Packages) []string {
+	var untested []string
+	for _, name := range sortedPackageNames(pkgs) {
+		if pkgs[name].NoTestFiles {
+			untested = append(untested, name)
+		}
+	}
+	return untested
+}
+
+// UntestedTable prints the packages without test files, for -no-test-files.
+func (w *consoleWriter) UntestedTable(untested []string) {
+	if len(untested) == 0 {
+		return
+	}
+
+	s := fmt.Sprintf("\nUntested packages: %d without test files", len(untested))
+	fmt.Fprintf(w.Output, "%s\n\n", colorize(s, cYellow, w.Color))
+
+	tbl := tablewriter.NewWriter(w.Output)
+
+	tbl.SetHeader([]string{
+		"Package",
+	})
+
+	tbl.SetAutoWrapText(false)
+
+	for _, name := range untested {
+		tbl.Append([]string{name})
+	}
+
+	tbl.Render()
+}
+
+// reportUntested reports each package without test files as an error failing the run.
+func reportUntested(w io.Writer, untested []string) {
+	for _, name := range untested {
+		reportIssue(w, true, "no-test-files", fmt.Sprintf("package %s has no test files", name))
+	}
+}
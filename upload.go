@@ -0,0 +1,459 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mfridman/tparse/parse"
+	"github.com/pkg/errors"
+)
+
+// uploadPlaceholderRe matches the placeholders of an -upload key template.
+var uploadPlaceholderRe = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// uploadReports uploads the reports written through flags, the -cover-html pages and a
+// summary.json of the run to dest, a URL whose path is a key template:
+//
+//	s3://bucket/key
+//	gs://bucket/key
+//	azblob://account/container/key
+//
+// Placeholders in the key, such as {branch}, {commit} and {run}, are replaced by
+// ciValue. Each file is uploaded under the key by its base name, directories by their
+// base name followed by the path of each file within them.
+func uploadReports(dest string, pkgs parse.Packages, exitCode int, opts reportOptions) error {
+	u, err := url.Parse(dest)
+	if err != nil {
+		return errors.Wrap(err, "invalid -upload")
+	}
+	var store uploader
+	switch u.Scheme {
+	case "s3":
+		store, err = newS3Uploader(u.Host)
+	case "gs":
+		store, err = newGCSUploader(u.Host)
+	case "azblob":
+		store, err = newAzureUploader(u.Host)
+	default:
+		return fmt.Errorf("invalid -upload %q: want s3://, gs:// or azblob://", dest)
+	}
+	if err != nil {
+		return err
+	}
+
+	prefix, err := expandUploadKey(strings.Trim(u.Path, "/"), opts.labels)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "azblob" && prefix == "" {
+		return fmt.Errorf("invalid -upload %q: want azblob://account/container/key", dest)
+	}
+
+	files := make(map[string]string) // by key
+	add := func(name string) error {
+		fi, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		if !fi.IsDir() {
+			files[path.Join(prefix, filepath.Base(name))] = name
+			return nil
+		}
+		return filepath.Walk(name, func(p string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(name, p)
+			if err != nil {
+				return err
+			}
+			files[path.Join(prefix, filepath.Base(name), filepath.ToSlash(rel))] = p
+			return nil
+		})
+	}
+	for _, f := range reportFiles() {
+		if err := add(f.path); err != nil {
+			return err
+		}
+	}
+	for _, name := range []string{*markdownPtr, *coverHTMLPtr} {
+		if name == "" {
+			continue
+		}
+		if err := add(name); err != nil {
+			return err
+		}
+	}
+
+	var summary bytes.Buffer
+	if err := writeSummaryJSON(&summary, pkgs, exitCode, opts); err != nil {
+		return err
+	}
+	if err := store.put(path.Join(prefix, "summary.json"), summary.Bytes(), "application/json"); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		by, err := ioutil.ReadFile(files[key])
+		if err != nil {
+			return err
+		}
+		contentType := mime.TypeByExtension(path.Ext(key))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		if err := store.put(key, by, contentType); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(os.Stderr, "tparse: uploaded %d file(s) to %s://%s/%s\n", len(keys)+1, u.Scheme, u.Host, prefix)
+	return nil
+}
+
+// expandUploadKey replaces the placeholders in the key template, failing for those
+// without a value.
+func expandUploadKey(template string, labels runLabels) (string, error) {
+	var err error
+	key := uploadPlaceholderRe.ReplaceAllStringFunc(template, func(s string) string {
+		name := s[1 : len(s)-1]
+		v := ciValue(name, labels)
+		if v == "" && err == nil {
+			err = fmt.Errorf("-upload: no value for %s: set it with -label %s=...", s, name)
+		}
+		return v
+	})
+	return key, err
+}
+
+// ciEnv are the environment variables holding the branch, commit and run ID in CI
+// systems, in order of precedence.
+var ciEnv = map[string][]string{
+	"branch": {
+		"GITHUB_HEAD_REF", "GITHUB_REF_NAME", // GitHub Actions
+		"CI_COMMIT_REF_NAME",     // GitLab
+		"BUILDKITE_BRANCH",       // Buildkite
+		"CIRCLE_BRANCH",          // CircleCI
+		"BUILD_SOURCEBRANCHNAME", // Azure Pipelines
+		"BRANCH_NAME",            // Jenkins
+	},
+	"commit": {
+		"GITHUB_SHA",
+		"CI_COMMIT_SHA",
+		"BUILDKITE_COMMIT",
+		"CIRCLE_SHA1",
+		"BUILD_SOURCEVERSION",
+		"GIT_COMMIT",
+	},
+	"run": {
+		"GITHUB_RUN_ID",
+		"CI_PIPELINE_ID",
+		"BUILDKITE_BUILD_NUMBER",
+		"CIRCLE_BUILD_NUM",
+		"BUILD_BUILDID",
+		"BUILD_NUMBER",
+	},
+}
+
+// ciValue returns the value of the key placeholder: the label of that name, otherwise
+// for branch, commit and run the value set by the CI system or, outside CI, git and
+// the current time.
+func ciValue(name string, labels runLabels) string {
+	for _, l := range labels {
+		if l.key == name {
+			return l.value
+		}
+	}
+	for _, env := range ciEnv[name] {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	switch name {
+	case "branch":
+		return gitOutput("rev-parse", "--abbrev-ref", "HEAD")
+	case "commit":
+		return gitOutput("rev-parse", "HEAD")
+	case "run":
+		return time.Now().UTC().Format("20060102T150405Z")
+	}
+	return ""
+}
+
+func gitOutput(args ...string) string {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// uploader stores objects in a bucket.
+type uploader interface {
+	put(key string, body []byte, contentType string) error
+}
+
+var uploadClient = &http.Client{Timeout: 60 * time.Second}
+
+// doUpload sends req, returning an error unless it succeeded.
+func doUpload(req *http.Request) error {
+	resp, err := uploadClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("upload %s: %s: %s", req.URL.Path, resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// s3Uploader uploads to Amazon S3, or a compatible store at AWS_ENDPOINT_URL, in the
+// region of AWS_REGION, signing requests with the credentials in AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+type s3Uploader struct {
+	bucket, region, endpoint string
+	accessKey, secretKey     string
+	sessionToken             string
+}
+
+func newS3Uploader(bucket string) (*s3Uploader, error) {
+	s := &s3Uploader{
+		bucket:       bucket,
+		region:       os.Getenv("AWS_REGION"),
+		endpoint:     strings.TrimRight(os.Getenv("AWS_ENDPOINT_URL"), "/"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if s.region == "" {
+		s.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if s.region == "" {
+		return nil, errors.New("AWS_REGION must be set to the region of the bucket")
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	return s, nil
+}
+
+func (s *s3Uploader) put(key string, body []byte, contentType string) error {
+	req, err := s.request(key, body, contentType, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	return doUpload(req)
+}
+
+// request returns the signed request uploading body to key.
+func (s *s3Uploader) request(key string, body []byte, contentType string, now time.Time) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPut, s.url(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	s.sign(req, body, now)
+	return req, nil
+}
+
+// url returns the URL of the object key. Custom endpoints are addressed path-style, as
+// most S3 compatible stores expect.
+func (s *s3Uploader) url(key string) string {
+	if s.endpoint != "" {
+		return s.endpoint + "/" + s3Escape(s.bucket) + "/" + s3Escape(key)
+	}
+	return "https://" + s.bucket + ".s3." + s.region + ".amazonaws.com/" + s3Escape(key)
+}
+
+// sign signs req, and all of its headers, with AWS Signature Version 4.
+func (s *s3Uploader) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256.Sum256(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+
+	headers := []string{"host"}
+	for h := range req.Header {
+		headers = append(headers, strings.ToLower(h))
+	}
+	sort.Strings(headers)
+	var canonicalHeaders strings.Builder
+	for _, h := range headers {
+		v := strings.Join(req.Header.Values(h), ",")
+		if h == "host" {
+			v = req.URL.Host
+		}
+		canonicalHeaders.WriteString(h + ":" + strings.TrimSpace(v) + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{date, s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Escape escapes an object key as AWS Signature Version 4 requires: everything but
+// unreserved characters and slashes.
+func s3Escape(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// gcsUploader uploads to Google Cloud Storage with the OAuth access token in
+// GOOGLE_OAUTH_ACCESS_TOKEN or, on Google Cloud, that of the instance service account.
+type gcsUploader struct {
+	bucket, token string
+}
+
+func newGCSUploader(bucket string) (*gcsUploader, error) {
+	token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN")
+	if token == "" {
+		var err error
+		if token, err = gceAccessToken(); err != nil {
+			return nil, errors.Wrap(err, "GOOGLE_OAUTH_ACCESS_TOKEN must be set outside Google Cloud")
+		}
+	}
+	return &gcsUploader{bucket: bucket, token: token}, nil
+}
+
+// gceAccessToken returns an access token from the metadata server of Google Cloud.
+func gceAccessToken() (string, error) {
+	req, err := http.NewRequest(http.MethodGet,
+		"http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("metadata server: %s", resp.Status)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+func (g *gcsUploader) put(key string, body []byte, contentType string) error {
+	req, err := g.request(key, body, contentType)
+	if err != nil {
+		return err
+	}
+	return doUpload(req)
+}
+
+// request returns the request uploading body to key.
+func (g *gcsUploader) request(key string, body []byte, contentType string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPut,
+		"https://storage.googleapis.com/"+g.bucket+"/"+s3Escape(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+g.token)
+	return req, nil
+}
+
+// azureUploader uploads to Azure Blob Storage with the shared access signature in
+// AZURE_STORAGE_SAS_TOKEN. The host of the -upload URL is the storage account, and the
+// first element of its path the container.
+type azureUploader struct {
+	account, sas string
+}
+
+func newAzureUploader(account string) (*azureUploader, error) {
+	sas := strings.TrimPrefix(os.Getenv("AZURE_STORAGE_SAS_TOKEN"), "?")
+	if sas == "" {
+		return nil, errors.New("AZURE_STORAGE_SAS_TOKEN must be set")
+	}
+	return &azureUploader{account: account, sas: sas}, nil
+}
+
+func (a *azureUploader) put(key string, body []byte, contentType string) error {
+	req, err := a.request(key, body, contentType)
+	if err != nil {
+		return err
+	}
+	return doUpload(req)
+}
+
+// request returns the request uploading body to key.
+func (a *azureUploader) request(key string, body []byte, contentType string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodPut,
+		"https://"+a.account+".blob.core.windows.net/"+s3Escape(key)+"?"+a.sas, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Ms-Blob-Type", "BlockBlob")
+	req.Header.Set("X-Ms-Version", "2020-10-02")
+	return req, nil
+}
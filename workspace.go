@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mfridman/tparse/parse"
+)
+
+// findWorkspace returns the go.work file used for the directory dir, found as the go
+// command does: the file named by GOWORK, none if GOWORK is off, or else the first
+// go.work in dir or its parents. It returns "" if there is none.
+func findWorkspace(dir string) string {
+	switch gowork := os.Getenv("GOWORK"); gowork {
+	case "off":
+		return ""
+	case "":
+	default:
+		return gowork
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		name := filepath.Join(dir, "go.work")
+		if fi, err := os.Stat(name); err == nil && !fi.IsDir() {
+			return name
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// readWorkspaceModules returns the paths of the modules used by the go.work file name,
+// read from their go.mod files, sorted. A missing go.work, or an empty name, returns nil.
+func readWorkspaceModules(name string) []string {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	// use ./a
+	// use (
+	// 	./b
+	// )
+	var dirs []string
+	var block bool
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case block && fields[0] == ")":
+			block = false
+		case block:
+			dirs = append(dirs, fields[0])
+		case fields[0] == "use" && len(fields) > 1 && fields[1] == "(":
+			block = true
+		case fields[0] == "use" && len(fields) > 1:
+			dirs = append(dirs, fields[1])
+		}
+	}
+
+	var modules []string
+	for _, dir := range dirs {
+		if s, err := strconv.Unquote(dir); err == nil {
+			dir = s
+		}
+		if mod := readModulePath(filepath.Join(filepath.Dir(name), dir, "go.mod")); mod != "" {
+			modules = append(modules, mod)
+		}
+	}
+	sort.Strings(modules)
+	return modules
+}
+
+// moduleOf returns the module of modules that package pkg belongs to, the one with the
+// longest matching path, or "" if none.
+func moduleOf(modules []string, pkg string) string {
+	var mod string
+	for _, m := range modules {
+		if (pkg == m || strings.HasPrefix(pkg, m+"/")) && len(m) > len(mod) {
+			mod = m
+		}
+	}
+	return mod
+}
+
+// groupByModule sorts the summary table rows by module and package, following the
+// rows of each module with a subtotal row. Rows are returned unchanged if they span
+// fewer than two modules.
+func (w *consoleWriter) groupByModule(
+	rows [][]string,
+	pkgs parse.Packages,
+	modules []string,
+	testsByAction func(*parse.Package, parse.Action) []*parse.Test,
+) [][]string {
+	// The package column may be followed by notes on following lines.
+	rowPackage := func(row []string) string {
+		return strings.SplitN(row[4], "\n", 2)[0]
+	}
+
+	groups := make(map[string][][]string)
+	for _, row := range rows {
+		mod := moduleOf(modules, rowPackage(row))
+		groups[mod] = append(groups[mod], row)
+	}
+	if len(groups) < 2 {
+		return rows
+	}
+
+	names := make([]string, 0, len(groups))
+	for mod := range groups {
+		names = append(names, mod)
+	}
+	sort.Strings(names)
+	// Packages outside of the workspace modules go last.
+	if names[0] == "" {
+		names = append(names[1:], "")
+	}
+
+	grouped := make([][]string, 0, len(rows)+len(groups))
+	for _, mod := range names {
+		group := groups[mod]
+		sort.SliceStable(group, func(i, j int) bool {
+			return rowPackage(group[i]) < rowPackage(group[j])
+		})
+		grouped = append(grouped, group...)
+
+		var failed bool
+		var testTime, coverage float64
+		var covered, pass, fail, skip int
+		for _, row := range group {
+			pkg := pkgs[rowPackage(row)]
+			if pkg == nil {
+				continue
+			}
+			if pkg.HasPanic || pkg.Summary.Action == parse.ActionFail {
+				failed = true
+			}
+			if pkg.HasPanic {
+				continue
+			}
+			if !pkg.Cached {
+				testTime += pkg.Stats().Total
+			}
+			if pkg.Cover {
+				coverage += pkg.Coverage
+				covered++
+			}
+			pass += len(testsByAction(pkg, parse.ActionPass))
+			fail += len(testsByAction(pkg, parse.ActionFail))
+			skip += len(testsByAction(pkg, parse.ActionSkip))
+		}
+
+		action := parse.ActionPass
+		if failed {
+			action = parse.ActionFail
+		}
+		cover := "--"
+		if covered > 0 {
+			// The mean over packages, as statement counts are not known.
+			c := coverage / float64(covered)
+			cover = fmt.Sprintf("%.1f%%", c)
+			if *coverBarPtr {
+				cover = coverageBar(c) + " " + cover
+			}
+			if !failed {
+				cover = colorize(cover, coverageColor(c), w.Color)
+			}
+		}
+		label := "module " + mod
+		if mod == "" {
+			label = "other packages"
+		}
+
+		grouped = append(grouped, []string{
+			withColor(action, w.Color),
+			"--",
+			"--",
+			strconv.FormatFloat(testTime, 'f', 2, 64) + "s",
+			label,
+			cover,
+			strconv.Itoa(pass),
+			strconv.Itoa(fail),
+			strconv.Itoa(skip),
+		})
+	}
+	return grouped
+}
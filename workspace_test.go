@@ -0,0 +1,60 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindWorkspace(t *testing.T) {
+
+	defer os.Setenv("GOWORK", os.Getenv("GOWORK"))
+
+	root := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		name = filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("go.work", "go 1.21\n\nuse (\n\t./a\n\t\"./b\" // b\n)\n")
+	write("a/go.mod", "module example.com/a\n")
+	write("b/go.mod", "module example.com/b\n")
+	write("other.work", "use ./a\n")
+	nested := filepath.Join(root, "a", "internal", "pkg")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		gowork, dir string
+		want        string
+		modules     []string
+	}{
+		// 0
+		{"", root, filepath.Join(root, "go.work"), []string{"example.com/a", "example.com/b"}},
+		// 1, found in a parent directory
+		{"", nested, filepath.Join(root, "go.work"), []string{"example.com/a", "example.com/b"}},
+		// 2
+		{filepath.Join(root, "other.work"), nested, filepath.Join(root, "other.work"), []string{"example.com/a"}},
+		// 3
+		{"off", nested, "", nil},
+	}
+
+	for i, test := range tt {
+		os.Setenv("GOWORK", test.gowork)
+		got := findWorkspace(test.dir)
+		if got != test.want {
+			t.Errorf("%d: got %q, want %q", i, got, test.want)
+		}
+		if modules := readWorkspaceModules(got); !reflect.DeepEqual(modules, test.modules) {
+			t.Errorf("%d: got modules %q, want %q", i, modules, test.modules)
+		}
+	}
+}